@@ -19,10 +19,15 @@ import (
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"text/template"
 
 	"github.com/richarda23/rspace-client-go/rspace"
 	"github.com/spf13/cobra"
@@ -30,13 +35,27 @@ import (
 
 type addDocArgs struct {
 	ParentfolderArg string
+	NotebookArg     string
 	NameArg         string
 	Tags            string
 	ContentFile     string
+	ContentFormat   string
+	ContentTemplate string
+	Vars            []string
+	VarsFile        string
+	Strict          bool
 	Content         string
 	FormId          string
 	InputData       string
 	InputDataFormat string
+	Sheet           string
+	MappingFile     string
+	NameTemplate    string
+	TagsColumn      string
+	ResultsFile     string
+	FailFast        bool
+	Rollback        bool
+	DryRun          bool
 }
 
 var addDocArgV = addDocArgs{}
@@ -46,16 +65,57 @@ var addDocumentCmd = &cobra.Command{
 	Use:   "addDocument",
 	Short: "Creates a new Basic or Structured document with optional tags and content",
 	Long: `Create a new document, with an optional name and parent folder.
+Documents are created in your home folder, unless a folder is set with --folder,
+or a notebook with --notebook, in which case they are created as notebook entries.
 If a file is a file of HTML content, it is loaded verbatim, otherwise, plain text files are wrapped in 'pre'
-tags to preserve formatting.
+tags to preserve formatting. Markdown files, ending in '.md', are converted to HTML. Images in Markdown
+that are local files are uploaded to the Gallery, and linked in the document. --content-format sets the format
+of --content or --file, if not given by the file's extension: html, text or markdown.
+
+Content can also be made from an HTML template file, using Go's html/template syntax, e.g. '<p>Sample {{.sample}}</p>'.
+Template variables are set with --var 'name=value', or in a --vars file of 'name=value' lines, or a JSON or YAML object.
+Values are escaped, and every variable the template uses must be set.
+
+HTML content is cleaned before it's added: elements and attributes that RSpace's editor doesn't support
+are removed, as are scripts, frames, event handlers and 'javascript:' links. Warnings are written of
+what was changed, and of other problems such as unclosed tags, or images with relative paths, which RSpace
+can't find. With --strict, content with any of these problems is refused, and no document is created.
 
 You can also create an structured (multi-field) document by passing the 'formId'.
-This creates an empty document. You can also create 1 or more documents from a CSV
-file with the following characteristics:
-- 1st row is a header row and is ignored
+This creates an empty document. You can also create 1 or more documents from a file of
+input data in CSV, JSON, JSON Lines, YAML or XLSX format, chosen by the file's extension or --inputFormat.
+A CSV file, or a sheet of an XLSX file chosen with --sheet, should have the following characteristics:
+- 1st row is a header row, with the names of the form fields that each column supplies data for.
+  Names are matched ignoring case, and columns can be in any order.
 - Each row will supply data to create an RSpace document
-- The column order should match the fields in the Form definition
-- The total number of columns should match the total number of fields in the Form
+- There should be a column for every field in the Form
+
+JSON and YAML files hold a list of objects, and JSON Lines files an object per line, keyed by field name.
+Each object will supply data to create an RSpace document. Lists of values, e.g. for Choice fields,
+are joined with commas. These are read as a table with a column for each key, so the rest of this
+help applies to them as well.
+
+Values are checked against the type of their field: Number fields must be numbers, Date fields must be
+dates in the format yyyy-mm-dd, and Choice and Radio fields must be one of the field's options.
+Several options of a Choice field can be separated by commas. Rows with invalid values are reported and skipped.
+
+If the columns don't match the field names, supply a --mapping file with a line for each column to use,
+of the form 'column=field'. The field can be a field name or number, counting from 1. Other columns are ignored,
+and fields without a column are left empty. Lines starting with '#' are ignored.
+
+Documents created from a file are named --name, then --name-2, --name-3 and so on, numbered by row.
+Without --name, they are named after the input file, e.g. data, data-2 for data.csv.
+Alternatively, --name-template names each document from its row's values, referring to columns by
+their header, e.g. '{{.Date}}-{{.Sample}}'. Headers containing spaces can be used as '{{index . "Sample ID"}}'.
+--tags-column names a column of comma-separated tags, which are added to any --tags. This column needn't
+match a form field.
+
+Every row is validated before any documents are created. Rows that are invalid, or can't be created,
+are reported and the rest are created, unless --fail-fast is set, which stops at the first failure.
+If any rows are invalid, --fail-fast creates nothing. --rollback also stops at the first failure, and deletes
+the documents already created, so that either every row is created or none are. --dry-run just validates the rows.
+--results writes what happened to each row, and the id of its document, to a CSV file, or JSON if the
+file name ends in '.json'.
 	`,
 	Example: `
 // create a new document with tags and HTML content
@@ -64,6 +124,13 @@ rspace eln  addDocument --name doc1 --tags tag1,tag2 --contentFile textToPutInDo
 // create a doc with tags and  plain-text content, which will be wrapped in '<pre>' tag
 rspace eln  addDocument --name doc1 --tags tag1,tag2 --contentFile textToPutInDoc.txt
 
+// create a doc from Markdown notes, uploading the images they refer to
+rspace eln  addDocument --name doc1 --file notes.md
+
+// create a doc from a protocol template
+rspace eln  addDocument --name "PCR ABC" --content-template pcr.html --var sample=ABC --var date=2021-03-04
+rspace eln  addDocument --name "PCR ABC" --content-template pcr.html --vars pcrVars.yaml --var sample=ABC
+
 // create a doc using verbatim text
 rspace eln  addDocument --name doc1  --content "some content"
 
@@ -73,13 +140,33 @@ rspace eln addDocument --name myDoc --formId FM2
 // create a multi-field document with data in a CSV file:
 rspace eln addDocument --name myDoc --formId FM2 --input data.csv
 
+// create documents from a list of objects in a JSON file, or from a sheet of an Excel workbook
+rspace eln addDocument --name myDoc --formId FM2 --input data.json
+rspace eln addDocument --name myDoc --formId FM2 --input data.xlsx --sheet Samples
+
+// use a mapping file if the CSV columns don't match the field names
+rspace eln addDocument --name myDoc --formId FM2 --input data.csv --mapping columns.txt
+
+// name each document from its row, and tag it from the 'Keywords' column
+rspace eln addDocument --formId FM2 --input data.csv --name-template '{{.Date}}-{{.Sample}}' --tags-column Keywords
+
+// check a file of data, then create all of its documents or none, recording the ids created
+rspace eln addDocument --name run --formId FM2 --input data.csv --dry-run
+rspace eln addDocument --name run --formId FM2 --input data.csv --rollback --results results.csv
+
+// create a document in a folder
+rspace eln addDocument --name doc1 --content "some content" --folder FL123
+
+// create documents from a CSV file as entries in a notebook
+rspace eln addDocument --name run --formId FM2 --input data.csv --notebook NB456
+
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
-		err := doAddDocRun(addDocArgV, context, context.WebClient)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContext()
 		if err != nil {
-			exitWithErr(err)
+			return err
 		}
+		return doAddDocRun(addDocArgV, context, context.WebClient)
 	},
 }
 
@@ -89,25 +176,69 @@ type DocClient interface {
 	NewDocumentWithContent(post *rspace.DocumentPost) (*rspace.Document, error)
 }
 
-func doAddDocRun(addDocArgV addDocArgs, context *Context, docClient DocClient) error {
+// FileUploadClient uploads files to the Gallery
+type FileUploadClient interface {
+	UploadFile(config rspace.FileUploadConfig) (*rspace.FileInfo, error)
+}
+
+// AddDocClient creates documents, looking up the folder and form they are created from,
+// uploading images in their content, and deleting them again if a batch is rolled back
+type AddDocClient interface {
+	DocClient
+	FileUploadClient
+	FolderById(id int) (*rspace.Folder, error)
+	FormById(id int) (*rspace.Form, error)
+	DeleteDocument(id int) (bool, error)
+}
+
+// DocEditClient reads, edits and deletes existing documents
+type DocEditClient interface {
+	DocClient
+	DocumentById(id int) (*rspace.Document, error)
+	DocumentEdit(id int, post *rspace.DocumentPost) (*rspace.Document, error)
+	DeleteDocument(id int) (bool, error)
+}
+
+func doAddDocRun(addDocArgV addDocArgs, context *Context, docClient AddDocClient) error {
 	var created *rspace.Document
 	var err error
 	// is basic document if no form is supplied,
 	// we make a basic document
 	createdDocs := make([]*rspace.DocumentInfo, 0)
+	if err := validateBatchArgs(addDocArgV); err != nil {
+		return err
+	}
+	parentFolderId, err := targetFolderId(docClient, addDocArgV)
+	if err != nil {
+		return err
+	}
 	if len(addDocArgV.FormId) == 0 {
-		content := getContent(addDocArgV)
-		created, err = docClient.NewBasicDocumentWithContent(addDocArgV.NameArg,
-			addDocArgV.Tags, content)
+		content, err := getContent(context, docClient, addDocArgV)
+		if err != nil {
+			return err
+		}
+		if parentFolderId == 0 {
+			created, err = docClient.NewBasicDocumentWithContent(addDocArgV.NameArg,
+				addDocArgV.Tags, content)
+		} else {
+			created, err = docClient.NewDocumentWithContent(&rspace.DocumentPost{Name: addDocArgV.NameArg,
+				Tags: addDocArgV.Tags, ParentFolderId: parentFolderId,
+				Fields: []rspace.FieldContent{rspace.FieldContent{Content: content}}})
+		}
+		if err != nil {
+			return err
+		}
 		createdDocs = append(createdDocs, created.DocumentInfo)
+	} else if len(addDocArgV.ContentTemplate) > 0 {
+		return newCliError(EXIT_VALIDATION, "--content-template can't be used with --formId, it sets the content of a basic document")
 	} else {
 		// we make a sructured document
-		createdDocs, err = readDocContentFromFile(addDocArgV, docClient)
+		createdDocs, err = readDocContentFromFile(addDocArgV, parentFolderId, context, docClient)
+		if err != nil {
+			return err
+		}
 	}
 
-	if err != nil {
-		return err
-	}
 	docList := rspace.DocumentList{}
 	// deref pointers to fit into DocumentList
 	toList := make([]rspace.DocumentInfo, 0)
@@ -116,12 +247,64 @@ func doAddDocRun(addDocArgV addDocArgs, context *Context, docClient DocClient) e
 	}
 	docList.Documents = toList
 	var dlf = DocListFormatter{&docList}
-	context.writeResult(&dlf)
-	return nil
+	if err := context.writeResult(&dlf); err != nil {
+		return err
+	}
+	return context.itemFailuresError()
 }
 
-// called when formId is not set.
-func readDocContentFromFile(addDocArgV addDocArgs, docClient DocClient) ([]*rspace.DocumentInfo, error) {
+// validateBatchArgs checks that options for creating documents from rows of input data are only used with --input
+func validateBatchArgs(addDocArgV addDocArgs) error {
+	if len(addDocArgV.InputData) > 0 {
+		return nil
+	}
+	var batchArg string
+	switch {
+	case addDocArgV.DryRun:
+		batchArg = "--dry-run"
+	case addDocArgV.FailFast:
+		batchArg = "--fail-fast"
+	case addDocArgV.Rollback:
+		batchArg = "--rollback"
+	case len(addDocArgV.ResultsFile) > 0:
+		batchArg = "--results"
+	default:
+		return nil
+	}
+	return newCliError(EXIT_VALIDATION, fmt.Sprintf("%s can only be used when creating documents from --input", batchArg))
+}
+
+// targetFolderId is the id of the folder or notebook to create documents in, or 0 for the home folder.
+// The target must exist and be of the type requested.
+func targetFolderId(docClient AddDocClient, addDocArgV addDocArgs) (int, error) {
+	folderArg, wantNotebook := addDocArgV.ParentfolderArg, false
+	if len(addDocArgV.NotebookArg) > 0 {
+		if len(folderArg) > 0 {
+			return 0, newCliError(EXIT_VALIDATION, "Please use either --folder or --notebook, not both")
+		}
+		folderArg, wantNotebook = addDocArgV.NotebookArg, true
+	}
+	if len(folderArg) == 0 {
+		return 0, nil
+	}
+	id, err := idFromGlobalId(folderArg)
+	if err != nil || id == 0 {
+		return 0, newCliError(EXIT_VALIDATION, fmt.Sprintf("%s is not a valid folder or notebook id", folderArg))
+	}
+	folder, err := docClient.FolderById(id)
+	if err != nil {
+		return 0, err
+	}
+	if wantNotebook && !folder.Notebook {
+		return 0, newCliError(EXIT_VALIDATION, fmt.Sprintf("%s is a folder, not a notebook. Please use --folder", folder.GlobalId))
+	} else if !wantNotebook && folder.Notebook {
+		return 0, newCliError(EXIT_VALIDATION, fmt.Sprintf("%s is a notebook, not a folder. Please use --notebook", folder.GlobalId))
+	}
+	return id, nil
+}
+
+// called when formId is set.
+func readDocContentFromFile(addDocArgV addDocArgs, parentFolderId int, ctx *Context, docClient AddDocClient) ([]*rspace.DocumentInfo, error) {
 	createdDocs := make([]*rspace.DocumentInfo, 0)
 
 	// else is form, we add content if there is any
@@ -134,47 +317,129 @@ func readDocContentFromFile(addDocArgV addDocArgs, docClient DocClient) ([]*rspa
 	toPost.Name = addDocArgV.NameArg
 	toPost.Tags = addDocArgV.Tags
 	toPost.FormID = rspace.FormId{formId}
+	toPost.ParentFolderId = parentFolderId
 	if len(addDocArgV.InputData) > 0 {
-		f, err := os.Open(addDocArgV.InputData)
+		csvIn, err := readInputTable(addDocArgV.InputData, addDocArgV.InputDataFormat, addDocArgV.Sheet)
 		if err != nil {
 			return nil, err
 		}
-		csvIn, err := readCsvFile(f)
+		mapping, err := readFormMapping(docClient, formId, csvIn[0], addDocArgV)
 		if err != nil {
 			return nil, err
 		}
-		err = validateCsvInput(csvIn)
+		nameTemplate, err := parseNameTemplate(addDocArgV.NameTemplate, mapping)
 		if err != nil {
 			return nil, err
 		}
-		// iterate of each row in file
-		for i, v := range csvIn {
-			// ignore 1st line - header
-			if i == 0 {
-				continue
-			}
-			messageStdErr(fmt.Sprintf("%d of %d", i, len(csvIn)-1))
-			var content []rspace.FieldContent = make([]rspace.FieldContent, 0)
-			// convert each column into a field
-			for _, v2 := range v {
-				content = append(content, rspace.FieldContent{Content: v2})
-			}
-			toPost.Fields = content
-			// add suffix to name if > 1 document being created
-			if i > 1 {
-				toPost.Name = fmt.Sprintf("%s-%d", toPost.Name, i)
-			}
-			doc, err := docClient.NewDocumentWithContent(&toPost)
-			if err != nil {
-				messageStdErr(fmt.Sprintf("Could not create document from data in row %d", i))
-				continue
+		if nameTemplate == nil && len(toPost.Name) == 0 {
+			toPost.Name = defaultRowName(addDocArgV.InputData)
+		}
+		batch := &documentBatch{ctx: ctx, docClient: docClient, failFast: addDocArgV.FailFast,
+			rollback: addDocArgV.Rollback, dryRun: addDocArgV.DryRun}
+		// validate each row in file, ignoring the 1st line - header
+		for i, v := range csvIn[1:] {
+			post, err := rowDocumentPost(toPost, addDocArgV, mapping, nameTemplate, i+1, v)
+			batch.addRow(i+1, post, err)
+		}
+		createdDocs = batch.run()
+		if len(addDocArgV.ResultsFile) > 0 {
+			if err := batch.writeResults(addDocArgV.ResultsFile); err != nil {
+				return nil, err
 			}
-			createdDocs = append(createdDocs, doc.DocumentInfo)
 		}
 	}
 	return createdDocs, nil
 }
 
+// rowDocumentPost creates the document for a row of input, named and tagged from the row's values
+func rowDocumentPost(toPost rspace.DocumentPost, addDocArgV addDocArgs, mapping *formMapping,
+	nameTemplate *template.Template, rowNumber int, row []string) (*rspace.DocumentPost, error) {
+	content, err := mapping.fieldContent(row)
+	if err != nil {
+		return nil, err
+	}
+	toPost.Fields = content
+	values := mapping.rowValues(row)
+	if nameTemplate != nil {
+		var name strings.Builder
+		if err := nameTemplate.Execute(&name, values); err != nil {
+			return nil, err
+		}
+		toPost.Name = strings.TrimSpace(name.String())
+		if len(toPost.Name) == 0 {
+			return nil, newCliError(EXIT_VALIDATION, "--name-template gave an empty name")
+		}
+	} else if rowNumber > 1 {
+		// add suffix to name if > 1 document being created
+		toPost.Name = fmt.Sprintf("%s-%d", toPost.Name, rowNumber)
+	}
+	if len(addDocArgV.TagsColumn) > 0 {
+		tags := values[strings.TrimSpace(mapping.headers[mapping.columnIndex(addDocArgV.TagsColumn)])]
+		toPost.Tags = joinTags(toPost.Tags, tags)
+	}
+	return &toPost, nil
+}
+
+// defaultRowName is the base name of documents created from an input file when no --name is given,
+// the file name without its extension
+func defaultRowName(inputFile string) string {
+	base := filepath.Base(inputFile)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// joinTags combines comma-separated lists of tags, dropping blanks
+func joinTags(tagLists ...string) string {
+	tags := make([]string, 0)
+	for _, list := range tagLists {
+		for _, tag := range strings.Split(list, ",") {
+			if tag = strings.TrimSpace(tag); len(tag) > 0 {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return strings.Join(tags, ",")
+}
+
+// parseNameTemplate parses a --name-template, checking that it only refers to columns of the input
+func parseNameTemplate(templateArg string, mapping *formMapping) (*template.Template, error) {
+	if len(templateArg) == 0 {
+		return nil, nil
+	}
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(templateArg)
+	if err != nil {
+		return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("Invalid --name-template: %s", err.Error()))
+	}
+	if err = tmpl.Execute(ioutil.Discard, mapping.rowValues(nil)); err != nil {
+		return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("Invalid --name-template: %s. Columns are '%s'",
+			err.Error(), strings.Join(mapping.headers, "', '")))
+	}
+	return tmpl, nil
+}
+
+// readFormMapping fetches the form, and maps the input columns to its fields
+func readFormMapping(docClient AddDocClient, formId int, headers []string, addDocArgV addDocArgs) (*formMapping, error) {
+	var mapping map[string]string
+	if len(addDocArgV.MappingFile) > 0 {
+		var err error
+		if mapping, err = readKeyValueFile(addDocArgV.MappingFile, "column=field"); err != nil {
+			return nil, err
+		}
+	}
+	ignored := make([]string, 0)
+	if len(addDocArgV.TagsColumn) > 0 {
+		if !containsIgnoringCase(headers, addDocArgV.TagsColumn) {
+			return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("--tags-column '%s' is not a column of the input. Columns are '%s'",
+				addDocArgV.TagsColumn, strings.Join(headers, "', '")))
+		}
+		ignored = append(ignored, addDocArgV.TagsColumn)
+	}
+	form, err := docClient.FormById(formId)
+	if err != nil {
+		return nil, err
+	}
+	return newFormMapping(form, headers, mapping, ignored...)
+}
+
 // validates that csv input is suitable for creating documents from
 func validateCsvInput(csvIn [][]string) error {
 	if len(csvIn) <= 1 {
@@ -203,32 +468,141 @@ func readCsvFile(csvIn io.Reader) ([][]string, error) {
 	return result, nil
 }
 
-func getContent(addDocArgV addDocArgs) string {
+// formats of content given by --content or --file
+const (
+	CONTENT_FORMAT_HTML     = "html"
+	CONTENT_FORMAT_TEXT     = "text"
+	CONTENT_FORMAT_MARKDOWN = "markdown"
+)
+
+var contentFormats = []string{CONTENT_FORMAT_HTML, CONTENT_FORMAT_TEXT, CONTENT_FORMAT_MARKDOWN}
+
+// contentFormat is the format set with --content-format, or else given by the file's extension.
+// Content given with --content is HTML by default.
+func contentFormat(addDocArgV addDocArgs) (string, error) {
+	if len(addDocArgV.ContentFormat) > 0 {
+		format := strings.ToLower(addDocArgV.ContentFormat)
+		if format == "md" {
+			format = CONTENT_FORMAT_MARKDOWN
+		}
+		if !validateArrayContains(contentFormats, []string{format}) {
+			return "", newCliError(EXIT_VALIDATION, fmt.Sprintf("--content-format must be one of %s, but was '%s'",
+				strings.Join(contentFormats, ", "), addDocArgV.ContentFormat))
+		}
+		return format, nil
+	}
 	if len(addDocArgV.Content) > 0 {
-		return addDocArgV.Content
-	} else if len(addDocArgV.ContentFile) > 0 {
+		return CONTENT_FORMAT_HTML, nil
+	}
+	lowerCaseFile := strings.ToLower(addDocArgV.ContentFile)
+	if strings.HasSuffix(lowerCaseFile, "html") ||
+		strings.HasSuffix(lowerCaseFile, "htm") {
+		return CONTENT_FORMAT_HTML, nil
+	} else if strings.HasSuffix(lowerCaseFile, ".md") || strings.HasSuffix(lowerCaseFile, ".markdown") {
+		return CONTENT_FORMAT_MARKDOWN, nil
+	}
+	return CONTENT_FORMAT_TEXT, nil
+}
+
+func getContent(ctx *Context, uploader FileUploadClient, addDocArgV addDocArgs) (string, error) {
+	if len(addDocArgV.ContentTemplate) > 0 {
+		if len(addDocArgV.Content) > 0 || len(addDocArgV.ContentFile) > 0 {
+			return "", newCliError(EXIT_VALIDATION, "Please use either --content-template, --content or --file")
+		}
+		vars, err := templateVars(addDocArgV.VarsFile, addDocArgV.Vars)
+		if err != nil {
+			return "", err
+		}
+		content, err := renderContentTemplate(addDocArgV.ContentTemplate, vars)
+		if err != nil {
+			return "", err
+		}
+		return checkHtmlContent(ctx, content, addDocArgV.Strict)
+	}
+	content, baseDir := addDocArgV.Content, "."
+	if len(addDocArgV.Content) == 0 && len(addDocArgV.ContentFile) > 0 {
 		bytes, err := ioutil.ReadFile(addDocArgV.ContentFile)
 		if err != nil {
-			exitWithErr(err)
+			return "", err
 		}
-		lowerCaseFile := strings.ToLower(addDocArgV.ContentFile)
-		if strings.HasSuffix(lowerCaseFile, "html") ||
-			strings.HasSuffix(lowerCaseFile, "htm") {
-			return string(bytes)
-		} else {
-			return "<pre>" + string(bytes) + "</pre>"
+		content, baseDir = string(bytes), filepath.Dir(addDocArgV.ContentFile)
+	} else if len(content) == 0 {
+		return "", nil
+	}
+	format, err := contentFormat(addDocArgV)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case CONTENT_FORMAT_MARKDOWN:
+		if content, err = renderMarkdown(content, galleryImageLinker(ctx, uploader, baseDir)); err != nil {
+			return "", err
 		}
+	case CONTENT_FORMAT_TEXT:
+		return wrapPlainText(content), nil
 	}
-	return ""
+	return checkHtmlContent(ctx, content, addDocArgV.Strict)
+}
+
+var urlSchemeRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]+:`)
+
+// galleryImageLinker uploads local images to the Gallery, linking to them in the content.
+// Paths are relative to baseDir. Images on the web are linked by their URL.
+func galleryImageLinker(ctx *Context, uploader FileUploadClient, baseDir string) markdownImageLinker {
+	uploaded := make(map[string]*rspace.FileInfo)
+	return func(src, alt string) (string, error) {
+		if urlSchemeRegexp.MatchString(src) {
+			return markdownImageTag(src, alt), nil
+		}
+		path, err := url.PathUnescape(src)
+		if err != nil {
+			path = src
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, filepath.FromSlash(path))
+		}
+		info, ok := uploaded[path]
+		if !ok {
+			if _, err := os.Stat(path); err != nil {
+				return "", newCliError(EXIT_VALIDATION, fmt.Sprintf("Image '%s' can't be uploaded: %s", src, err.Error()))
+			}
+			if info, err = uploader.UploadFile(rspace.FileUploadConfig{FilePath: path, Caption: alt}); err != nil {
+				return "", err
+			}
+			ctx.messageStdErr(fmt.Sprintf("Uploaded image %s to the Gallery as %s", src, info.GlobalId))
+			uploaded[path] = info
+		}
+		return fmt.Sprintf("<fileId=%d>", info.Id), nil
+	}
+}
+
+// wrapPlainText preserves the formatting of plain text in HTML content, escaping any markup
+func wrapPlainText(text string) string {
+	return "<pre>" + html.EscapeString(text) + "</pre>"
 }
 
 func init() {
 	elnCmd.AddCommand(addDocumentCmd)
 	addDocumentCmd.Flags().StringVar(&addDocArgV.NameArg, "name", "", "A name for the document")
 	addDocumentCmd.Flags().StringVar(&addDocArgV.ParentfolderArg, "folder", "", "An id for the folder that will contain the new document")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.NotebookArg, "notebook", "", "An id for a notebook to create the new document as an entry of")
 	addDocumentCmd.Flags().StringVar(&addDocArgV.Tags, "tags", "", "One or more tags, comma separated")
 	addDocumentCmd.Flags().StringVar(&addDocArgV.ContentFile, "file", "", "A file of text or HTML content to put in a basic document")
 	addDocumentCmd.Flags().StringVar(&addDocArgV.Content, "content", "", "Text or HTML content to put in a basic document")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.ContentFormat, "content-format", "", "Format of --content or --file: "+strings.Join(contentFormats, ", "))
+	addDocumentCmd.Flags().StringVar(&addDocArgV.ContentTemplate, "content-template", "", "An HTML template file of content to put in a basic document")
+	addDocumentCmd.Flags().StringArrayVar(&addDocArgV.Vars, "var", []string{}, "A template variable, as 'name=value'. Can be repeated")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.VarsFile, "vars", "", "A file of template variables, as 'name=value' lines, or a JSON or YAML object")
+	addDocumentCmd.Flags().BoolVar(&addDocArgV.Strict, "strict", false, "Refuse HTML content that would need cleaning, rather than warning")
 	addDocumentCmd.Flags().StringVar(&addDocArgV.FormId, "formId", "", "Id for a form")
-	addDocumentCmd.Flags().StringVar(&addDocArgV.InputData, "input", "", "File of input data in CSV format for adding field Data to structured documents")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.InputData, "input", "", "File of input data in CSV, JSON, JSON Lines, YAML or XLSX format for adding field Data to structured documents")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.InputDataFormat, "inputFormat", "", "Format of the input data, if not given by its extension: "+strings.Join(inputDataFormats, ", "))
+	addDocumentCmd.Flags().StringVar(&addDocArgV.Sheet, "sheet", "", "Name or number of the sheet of XLSX input to read. Defaults to the 1st sheet")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.MappingFile, "mapping", "", "File of 'column=field' lines, mapping input columns to form fields")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.NameTemplate, "name-template", "", "Template naming each document from its input columns, e.g. '{{.Date}}-{{.Sample}}'")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.TagsColumn, "tags-column", "", "Input column of comma-separated tags for each document")
+	addDocumentCmd.Flags().StringVar(&addDocArgV.ResultsFile, "results", "", "File to write the result of each row of input data to, as CSV or JSON")
+	addDocumentCmd.Flags().BoolVar(&addDocArgV.FailFast, "fail-fast", false, "Stop at the first row of input data that fails")
+	addDocumentCmd.Flags().BoolVar(&addDocArgV.Rollback, "rollback", false, "If a row of input data fails, delete the documents already created from the input data")
+	addDocumentCmd.Flags().BoolVar(&addDocArgV.DryRun, "dry-run", false, "Validate the input data, without creating any documents")
 }
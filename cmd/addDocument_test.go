@@ -14,9 +14,10 @@ import (
 
 func TestGetContent(t *testing.T) {
 	// plain text content
+	ctx, client, _, _ := newMemoryContext("quiet")
 	args := addDocArgs{}
 	args.Content = "abcdefg"
-	content := getContent(args)
+	content, _ := getContent(ctx, client, args)
 
 	if content != "abcdefg" {
 		t.Fatalf("unexpected content")
@@ -25,14 +26,21 @@ func TestGetContent(t *testing.T) {
 	// text file
 	args.Content = ""
 	args.ContentFile = "testData/textContent.txt"
-	content = getContent(args)
+	content, _ = getContent(ctx, client, args)
 	if !strings.Contains(content, "<pre>") {
 		t.Fatalf("expected content should be wrapped in <pre> tag but was %s", content)
 	}
 
+	// markup in text is escaped, not added to the document
+	textFile, cleanup := writeTestInput(t, "payload.txt", []byte("pH < 7 & rising</pre><img src=x onerror=alert(1)>"))
+	defer cleanup()
+	args.ContentFile = textFile
+	content, _ = getContent(ctx, client, args)
+	assertEqualString(t, "<pre>pH &lt; 7 &amp; rising&lt;/pre&gt;&lt;img src=x onerror=alert(1)&gt;</pre>", content)
+
 	//html file
 	args.ContentFile = "testData/textContent.html"
-	content = getContent(args)
+	content, _ = getContent(ctx, client, args)
 	if !strings.Contains(content, "<p> some html </p>") {
 		t.Fatalf("expected verbatim html but was '%s'", content)
 	}
@@ -120,3 +128,92 @@ func (ds *DocumentAddOK) NewBasicDocumentWithContent(name, tags, content string)
 func (ds *DocumentAddOK) NewDocumentWithContent(post *rspace.DocumentPost) (*rspace.Document, error) {
 	return &rspace.Document{}, nil
 }
+
+func (ds *DocumentAddOK) FolderById(id int) (*rspace.Folder, error) {
+	return nil, newCliError(EXIT_NOT_FOUND, fmt.Sprintf("Folder %d not found", id))
+}
+
+func (ds *DocumentAddOK) FormById(id int) (*rspace.Form, error) {
+	return nil, newCliError(EXIT_NOT_FOUND, fmt.Sprintf("Form %d not found", id))
+}
+
+func (ds *DocumentAddOK) DeleteDocument(id int) (bool, error) {
+	return true, nil
+}
+
+func (ds *DocumentAddOK) UploadFile(config rspace.FileUploadConfig) (*rspace.FileInfo, error) {
+	return nil, newCliError(EXIT_VALIDATION, "Uploads aren't supported")
+}
+
+// creates a form with the columns of testData/ExperimentData.csv
+func addExperimentForm(client *memoryRSpace) *rspace.Form {
+	fields := make([]rspace.FormField, 0)
+	for _, name := range []string{"Date", "Objective", "Method", "Results", "Conclusion"} {
+		fields = append(fields, memoryFormField(name, "Text"))
+	}
+	return client.addForm("Experiment", fields)
+}
+
+func TestAddDocumentToFolderOrNotebook(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	folder := client.addFolder("Experiments", false, client.homeFolderId)
+	notebook := client.addFolder("Lab notebook", true, client.homeFolderId)
+	form := addExperimentForm(client)
+
+	err := doAddDocRun(addDocArgs{NameArg: "basic", Content: "hello", ParentfolderArg: folder.GlobalId}, ctx, client)
+	if err != nil {
+		t.Fatalf("Expected document in folder but got %v", err)
+	}
+	err = doAddDocRun(addDocArgs{NameArg: "run", FormId: form.GlobalId, InputData: "testData/ExperimentData.csv",
+		NotebookArg: notebook.GlobalId}, ctx, client)
+	if err != nil {
+		t.Fatalf("Expected notebook entries but got %v", err)
+	}
+	parents := make([]int, 0)
+	for _, doc := range client.documents {
+		parents = append(parents, doc.ParentFolderId)
+	}
+	if fmt.Sprint(parents) != fmt.Sprint([]int{folder.Id, notebook.Id, notebook.Id}) {
+		t.Errorf("Expected 1 document in %s and 2 in %s but parent folders were %v", folder.GlobalId, notebook.GlobalId, parents)
+	}
+}
+
+func TestAddDocumentLooksUpThroughInjectedClient(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	notebook := client.addFolder("Lab notebook", true, client.homeFolderId)
+	form := addExperimentForm(client)
+	ctx.WebClient = nil
+	err := doAddDocRun(addDocArgs{NameArg: "run", FormId: form.GlobalId, InputData: "testData/ExperimentData.csv",
+		NotebookArg: notebook.GlobalId}, ctx, client)
+	if err != nil {
+		t.Fatalf("Expected notebook entries from the injected client but got %v", err)
+	}
+	if len(client.documents) != 2 {
+		t.Errorf("Expected 2 documents but got %d", len(client.documents))
+	}
+}
+
+func TestAddDocumentTargetValidation(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	folder := client.addFolder("Experiments", false, client.homeFolderId)
+	notebook := client.addFolder("Lab notebook", true, client.homeFolderId)
+	invalid := map[int][]addDocArgs{
+		EXIT_VALIDATION: []addDocArgs{
+			addDocArgs{ParentfolderArg: notebook.GlobalId},
+			addDocArgs{NotebookArg: folder.GlobalId},
+			addDocArgs{ParentfolderArg: folder.GlobalId, NotebookArg: notebook.GlobalId},
+			addDocArgs{NotebookArg: "notAnId"},
+		},
+		EXIT_NOT_FOUND: []addDocArgs{addDocArgs{ParentfolderArg: "FL99999"}},
+	}
+	for code, argsList := range invalid {
+		for _, args := range argsList {
+			if err := doAddDocRun(args, ctx, client); exitCodeFor(err) != code {
+				t.Errorf("Expected exit code %d for %+v but got %v", code, args, err)
+			}
+		}
+	}
+	if len(client.documents) != 0 {
+		t.Errorf("Expected no documents to be created")
+	}
+}
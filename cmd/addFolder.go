@@ -35,49 +35,53 @@ Create a new Folder, with an optional name and parent folder ID
 // make a new folder in folder with id FL1234
 rspace eln addFolder --name MyFolder --folder FL1234
 	`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		post := rspace.FolderPost{IsNotebook: false}
-		ctx := initialiseContext()
-		doAddFolder(ctx, addFolderArgS, post)
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doAddFolder(ctx, addFolderArgS, post)
 	},
 }
 
-func doAddFolder(ctx *Context, args addFolderArg, post rspace.FolderPost) {
+func doAddFolder(ctx *Context, args addFolderArg, post rspace.FolderPost) error {
 	if len(args.Name) > 0 {
 		post.Name = args.Name
 	}
 	if len(args.ParentFolder) > 0 {
 		id, err := idFromGlobalId(args.ParentFolder)
-		if err != nil {
-			exitWithStdErrMsg("Please supply a folder id for the parent folder")
+		if err != nil || id == 0 {
+			return newCliError(EXIT_VALIDATION, "Please supply a folder id for the parent folder")
 		}
 		post.ParentFolderId = id
 	}
 
 	got, err := ctx.WebClient.FolderNew(&post)
 	if err != nil {
-		exitWithErr(err)
+		return err
 	}
 	if ctx.Format.isJson() {
 		ctx.write(prettyMarshal(got))
 	} else if ctx.Format.isTab() || ctx.Format.isCsv() {
-		folderToTable(ctx, got)
+		return folderToTable(ctx, got)
 	} else if ctx.Format.isQuiet() {
 		ctx.write(strconv.Itoa(got.Id))
 	} else {
 		ctx.write("unknown format")
 	}
+	return nil
 }
-func folderToTable(ctx *Context, folder *rspace.Folder) {
+func folderToTable(ctx *Context, folder *rspace.Folder) error {
 	headers := []columnDef{columnDef{"Id", 8}, columnDef{"GlobalId", 10}, columnDef{"Name", 25}, columnDef{"ParentFolderId", 15}, columnDef{"zd", 24}}
 	data := []string{strconv.Itoa(folder.Id), folder.GlobalId, folder.Name, strconv.Itoa(folder.ParentFolderId), folder.Created}
 	rows := make([][]string, 0)
 	rows = append(rows, data)
 	if ctx.Format.isCsv() {
-		printCsv(ctx, &TableResult{headers, rows})
-	} else {
-		printTable(ctx, &TableResult{headers, rows})
+		return printCsv(ctx, &TableResult{headers, rows})
 	}
+	printTable(ctx, &TableResult{headers, rows})
+	return nil
 }
 func init() {
 	elnCmd.AddCommand(addFolderCmd)
@@ -16,7 +16,6 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -52,27 +51,30 @@ immediately to create documents. If not set, forms remain in the 'NEW' state.
 // Publish both forms so they are available to use to create documents.
 rspace eln addForm myFormDef.yaml mySecondForm.json --publish
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := initialiseContext()
-		doCreateForms(args, ctx)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doCreateForms(args, ctx)
 	},
 }
 
-func doCreateForms(args []string, ctx *Context) {
+func doCreateForms(args []string, ctx *Context) error {
 	createdForms := make([]rspace.Form, 0)
 	// iterate over arguments
 	for _, v := range args {
 		form, err := createForm(v, ctx)
 		// file not exist, not a valid file, or create failed
 		if err != nil {
-			messageStdErr(fmt.Sprintf("%s -skipping", err.Error()))
+			ctx.reportItemFailure(v, err)
 			continue
 		}
 		createdForms = append(createdForms, *form)
 		if formArgs.Publish {
 			form, err = ctx.WebClient.PublishForm(form.Id)
 			if err != nil {
-				messageStdErr(err.Error())
+				ctx.reportItemFailure(v, err)
 				continue
 			} else {
 				// update with new publishing status
@@ -85,10 +87,13 @@ func doCreateForms(args []string, ctx *Context) {
 		fList := rspace.FormList{}
 		fList.Forms = createdForms
 		formatter := &FormListFormatter{&fList}
-		ctx.writeResult(formatter)
+		if err := ctx.writeResult(formatter); err != nil {
+			return err
+		}
 	} else {
-		messageStdErr("No forms created")
+		ctx.messageStdErr("No forms created")
 	}
+	return ctx.itemFailuresError()
 }
 
 func createForm(path string, ctx *Context) (*rspace.Form, error) {
@@ -46,24 +46,29 @@ rspace eln addGroup --name Bob-Group --pi bobsmith --members anabelz,sarahs
 # if the group name has spaces, enclose in double-quotes
 rspace eln addGroup --name "Prof Smith Lab" --pi bobsmith --members anabelz,sarahs
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		groupPost, err := validateGrpFlags()
 		if err != nil {
-			exitWithErr(err)
+			return newCliError(EXIT_VALIDATION, err.Error())
 		}
-		ctx := initialiseContext()
-		group, err := ctx.WebClient.GroupNew(groupPost)
+		ctx, err := initialiseContext()
 		if err != nil {
-			exitWithErr(err)
-		} else {
-
-			list := rspace.GroupList{[]*rspace.GroupInfo{group}}
-			formatter := &GroupListFormatter{&list}
-			ctx.writeResult(formatter)
+			return err
 		}
+		return doAddGroup(ctx, groupPost)
 	},
 }
 
+func doAddGroup(ctx *Context, groupPost *rspace.GroupPost) error {
+	group, err := ctx.WebClient.GroupNew(groupPost)
+	if err != nil {
+		return err
+	}
+	list := rspace.GroupList{[]*rspace.GroupInfo{group}}
+	formatter := &GroupListFormatter{&list}
+	return ctx.writeResult(formatter)
+}
+
 func validateGrpFlags() (*rspace.GroupPost, error) {
 	if len(groupArgs.GroupNameArg) == 0 {
 		return nil, errors.New("Group name is required, using --name flag")
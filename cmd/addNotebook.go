@@ -40,15 +40,18 @@ rspace eln addNotebook --name MyNotebook --folder FL1234
 //add an unnamed notebook in home folder
 rspace eln addNotebook
 	`,
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
-		doAddNotebook(context, addFolderArgS)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doAddNotebook(context, addNotebookArgS)
 	},
 }
 
-func doAddNotebook(ctx *Context, args addFolderArg) {
+func doAddNotebook(ctx *Context, args addFolderArg) error {
 	post := rspace.FolderPost{IsNotebook: true}
-	doAddFolder(ctx, args, post)
+	return doAddFolder(ctx, args, post)
 }
 
 func init() {
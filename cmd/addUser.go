@@ -39,22 +39,32 @@ var addUserCmd = &cobra.Command{
 	Example: ` 
 	addUser --username newusername --email someone@somwhere.com --role user|pi| --pwdfile passwordfile
 	`,
-	Run: func(cmd *cobra.Command, args []string) {
-		userPost := validateFlags()
-		ctx := initialiseContext()
-		user, err := ctx.WebClient.UserNew(userPost)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userPost, err := validateFlags()
 		if err != nil {
-			exitWithErr(err)
-		} else {
-			ctx.write(prettyMarshal(user))
+			return err
 		}
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doAddUser(ctx, userPost)
 	},
 }
 
-func validateFlags() *rspace.UserPost {
+func doAddUser(ctx *Context, userPost *rspace.UserPost) error {
+	user, err := ctx.WebClient.UserNew(userPost)
+	if err != nil {
+		return err
+	}
+	ctx.write(prettyMarshal(user))
+	return nil
+}
+
+func validateFlags() (*rspace.UserPost, error) {
 	pwd, err := ioutil.ReadFile(userArgs.PasswordFileArg)
 	if err != nil {
-		exitWithStdErrMsg("No password file supplied. Please put user password in a file and use the 'pwdfile' argument")
+		return nil, newCliError(EXIT_VALIDATION, "No password file supplied. Please put user password in a file and use the 'pwdfile' argument")
 	}
 	builder := &rspace.UserPostBuilder{}
 	builder.Password(string(pwd)).Username(userArgs.UsernameArg)
@@ -63,9 +73,9 @@ func validateFlags() *rspace.UserPost {
 	builder.Affiliation(userArgs.AffiliationArg)
 	post, e := builder.Role(getRoleForArg(userArgs.RoleArg)).Build()
 	if e != nil {
-		exitWithErr(e)
+		return nil, newCliError(EXIT_VALIDATION, e.Error())
 	}
-	return post
+	return post, nil
 }
 
 func getRoleForArg(arg string) rspace.UserRoleType {
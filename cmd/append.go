@@ -0,0 +1,144 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+	"github.com/spf13/cobra"
+)
+
+type appendArgs struct {
+	Field   int
+	Content string
+}
+
+var appendArgV = appendArgs{}
+
+// number of times a field is re-read if it changes while appending
+const APPEND_MAX_ATTEMPTS = 3
+
+// layout of the timestamp at the start of each appended block
+const APPEND_TIMESTAMP_LAYOUT = "2006-01-02 15:04:05"
+
+// clock for appended timestamps, which can be fixed in tests
+var appendClock = time.Now
+
+var appendCmd = &cobra.Command{
+	Use:   "append",
+	Short: "Appends a timestamped observation to a document field",
+	Long: `Append text to the end of a field, e.g. to log observations in the same notebook entry
+during a long experiment. The text is prefixed with the current time and wrapped in 'pre' tags,
+to preserve its formatting. If --content is not set, the text is read from stdin.
+
+Fields are numbered from 1. The field can be omitted for a Basic document, which has only 1 field.
+
+If the field is changed by someone else while appending, the latest content is re-read and the append is
+retried. If it keeps changing, the command fails with exit code 7, and nothing is appended.
+	`,
+	Example: `
+// append an observation to a Basic document
+rspace eln append SD123 --content "Colour changed to blue"
+
+// append to the 2nd field of a structured document
+rspace eln append SD123 --field 2 --content "Temperature stable at 37C"
+
+// append the output of a script
+./read-sensors.sh | rspace eln append SD123 --field 3
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doAppend(ctx, ctx.WebClient, args[0], appendArgV)
+	},
+}
+
+func doAppend(ctx *Context, docClient DocEditClient, idArg string, args appendArgs) error {
+	id, err := documentIdFromArg(idArg)
+	if err != nil {
+		return err
+	}
+	text := args.Content
+	if len(text) == 0 {
+		if ctx.Reader == nil && isTerminalWriter(os.Stdin) {
+			ctx.messageStdErr("Type the text to append, then Ctrl-D (Ctrl-Z and Enter on Windows)")
+		}
+		text, err = ctx.readAll()
+		if err != nil {
+			return err
+		}
+	}
+	text = strings.TrimRight(text, "\r\n")
+	if len(strings.TrimSpace(text)) == 0 {
+		return newCliError(EXIT_VALIDATION, "Please supply some content to append with --content or stdin")
+	}
+	block := wrapPlainText(fmt.Sprintf("[%s] %s", appendClock().Format(APPEND_TIMESTAMP_LAYOUT), text))
+
+	doc, err := docClient.DocumentById(id)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; attempt <= APPEND_MAX_ATTEMPTS; attempt++ {
+		field, err := fieldToUpdate(doc, args.Field)
+		if err != nil {
+			return err
+		}
+		// re-read just before updating, in case the field changed since it was read
+		latest, err := docClient.DocumentById(id)
+		if err != nil {
+			return err
+		}
+		latestField, err := fieldToUpdate(latest, args.Field)
+		if err != nil {
+			return err
+		}
+		if fieldVersion(doc, field) != fieldVersion(latest, latestField) {
+			ctx.messageStdErr(fmt.Sprintf("%s was modified while appending, retrying", doc.GlobalId))
+			doc = latest
+			continue
+		}
+		post := &rspace.DocumentPost{Fields: []rspace.FieldContent{rspace.FieldContent{Id: field.Id,
+			Content: field.Content + block}}}
+		updated, err := docClient.DocumentEdit(id, post)
+		if err != nil {
+			return err
+		}
+		return ctx.writeResult(&DocumentFormatter{updated})
+	}
+	return newCliError(EXIT_CONFLICT, fmt.Sprintf("%s kept being modified by someone else, nothing was appended. Please try again",
+		doc.GlobalId))
+}
+
+// fieldVersion is the field's last modification time, or the document's if the field's isn't known
+func fieldVersion(doc *rspace.Document, field *rspace.Field) string {
+	if len(field.LastModified) > 0 {
+		return field.LastModified
+	}
+	return doc.LastModified
+}
+
+func init() {
+	elnCmd.AddCommand(appendCmd)
+	appendCmd.Flags().IntVar(&appendArgV.Field, "field", 0, "Number of the field to append to, starting from 1")
+	appendCmd.Flags().StringVar(&appendArgV.Content, "content", "", "Text to append. If not set, text is read from stdin")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+func fieldContent(client *memoryRSpace, doc *rspace.Document) string {
+	latest, _ := client.DocumentById(doc.Id)
+	return latest.Fields[0].Content
+}
+
+// concurrentEditor changes a document's field each time it is read, as if someone else is editing it
+type concurrentEditor struct {
+	*memoryRSpace
+	edits int
+}
+
+func (c *concurrentEditor) DocumentById(id int) (*rspace.Document, error) {
+	if c.edits > 0 {
+		c.edits--
+		c.memoryRSpace.DocumentEdit(id, &rspace.DocumentPost{Fields: []rspace.FieldContent{{Content: "<p>edited</p>"}}})
+	}
+	return c.memoryRSpace.DocumentById(id)
+}
+
+func newAppendTestContext() (*Context, *memoryRSpace) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	// each timestamp is a second later than the last
+	tick := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	client.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+	appendClock = func() time.Time { return time.Date(2021, 3, 4, 11, 30, 0, 0, time.Local) }
+	return ctx, client
+}
+
+func TestAppendTimestampedBlock(t *testing.T) {
+	defer func() { appendClock = time.Now }()
+	ctx, client := newAppendTestContext()
+	doc, _ := client.NewBasicDocumentWithContent("log", "", "<p>start</p>")
+
+	if err := doAppend(ctx, client, doc.GlobalId, appendArgs{Content: "colour changed"}); err != nil {
+		t.Fatalf("Expected content to be appended but got %v", err)
+	}
+	ctx.Reader = strings.NewReader("line 1\nline 2\n")
+	if err := doAppend(ctx, client, doc.GlobalId, appendArgs{Field: 1}); err != nil {
+		t.Fatalf("Expected stdin to be appended but got %v", err)
+	}
+	expected := "<p>start</p><pre>[2021-03-04 11:30:00] colour changed</pre><pre>[2021-03-04 11:30:00] line 1\nline 2</pre>"
+	assertEqualString(t, expected, fieldContent(client, doc))
+}
+
+func TestAppendEscapesMarkup(t *testing.T) {
+	defer func() { appendClock = time.Now }()
+	ctx, client := newAppendTestContext()
+	doc, _ := client.NewBasicDocumentWithContent("log", "", "<p>start</p>")
+
+	if err := doAppend(ctx, client, doc.GlobalId, appendArgs{Content: "pH < 7 & rising <b>"}); err != nil {
+		t.Fatalf("Expected content to be appended but got %v", err)
+	}
+	expected := "<p>start</p><pre>[2021-03-04 11:30:00] pH &lt; 7 &amp; rising &lt;b&gt;</pre>"
+	assertEqualString(t, expected, fieldContent(client, doc))
+}
+
+func TestAppendRetriesAfterConcurrentEdit(t *testing.T) {
+	defer func() { appendClock = time.Now }()
+	ctx, client := newAppendTestContext()
+	doc, _ := client.NewBasicDocumentWithContent("log", "", "<p>start</p>")
+
+	editor := &concurrentEditor{client, 2}
+	if err := doAppend(ctx, editor, doc.GlobalId, appendArgs{Content: "ok"}); err != nil {
+		t.Fatalf("Expected append to succeed once edits stopped but got %v", err)
+	}
+	assertEqualString(t, "<p>edited</p><pre>[2021-03-04 11:30:00] ok</pre>", fieldContent(client, doc))
+
+	editor.edits = 10
+	err := doAppend(ctx, editor, doc.GlobalId, appendArgs{Content: "lost"})
+	if exitCodeFor(err) != EXIT_CONFLICT {
+		t.Errorf("Expected a conflict but got %v", err)
+	}
+	assertEqualString(t, "<p>edited</p>", fieldContent(client, doc))
+}
+
+func TestAppendValidation(t *testing.T) {
+	ctx, client := newAppendTestContext()
+	defer func() { appendClock = time.Now }()
+	doc, _ := client.NewBasicDocumentWithContent("log", "", "")
+	ctx.Reader = strings.NewReader("  \n")
+	if err := doAppend(ctx, client, doc.GlobalId, appendArgs{}); exitCodeFor(err) != EXIT_VALIDATION {
+		t.Errorf("Expected validation error for empty content but got %v", err)
+	}
+	if err := doAppend(ctx, client, doc.GlobalId, appendArgs{Field: 2, Content: "x"}); exitCodeFor(err) != EXIT_VALIDATION {
+		t.Errorf("Expected validation error for missing field but got %v", err)
+	}
+}
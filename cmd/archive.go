@@ -2,13 +2,13 @@ package cmd
 
 import (
 	"archive/zip"
-	"encoding/xml"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,20 +16,29 @@ import (
 
 var archiveCmd = &cobra.Command{
 	Use:   "archive",
-	Short: "Utility for inspecting RSpace XML archives",
-	Long: `Inspect and summarise XML archives and their manifest without opening or importing into RSpace.
+	Short: "Utility for inspecting RSpace XML or HTML archives",
+	Long: `Inspect and summarise XML or HTML archives and their manifest without opening or importing into RSpace.
+
+The archive format is detected automatically. HTML archives don't contain form definitions
+or a link map, so form counts are taken from each document's metadata and links are not counted.
 
 --summary argument calculates for one or more archives:
 
 - the total number of documents
-- the document creators
-- the date range of documents created in the archive
+- the document creators, and the number of documents created by each
+- the date range of documents created and last modified in the archive
+- the number of documents created from each form
+- the number and total size of attachments in the 'resources' folder
+- the number of cross-document links
 
 Results are printed 1 row per archive file.
 
 --xsummary works with a *single* archive only and lists information about each document in the archive, including name, tags, modification/creation dates and owner.
 
  Results are printed 1 row per *document*.
+
+To re-create the documents of an XML archive in RSpace, use the 'import' subcommand.
+To tabulate the field values of structured documents in an XML archive, use the 'fields' subcommand.
 `,
 	Args: cobra.MinimumNArgs(1),
 	Example: `
@@ -39,31 +48,46 @@ rspace archive myArchive.zip --manifest
 //summarise the content in one or more archive files
 rspace archive myArchive.zip otherArchive.zip --summary
 
+//summarise many large archives, processing 2 at a time to limit memory use
+rspace archive *.zip --summary --parallel 2
+
 //extended summary of a single archive in csv format
 rspace archive myArchive.zip --xsummary --outputFormat csv
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := initialiseContext()
-		if archiveArgsA.summaryArg {
-			summaries, err := inspectArchives(args, &archiveArgsA)
-			if err != nil {
-				exitWithErr(err)
-			}
-			ctx.writeResult(&zipSummaryFormatter{&zipSummaryList{summaries}})
-		} else if archiveArgsA.summaryXArg {
-			summaries, err := xSummary(args, &archiveArgsA)
-			if len(summaries) == 0 {
-				exitWithStdErrMsg("No documents to summarise")
-			}
-			if err != nil {
-				exitWithErr(err)
-			}
-			ctx.writeResult(&xSummaryFormatter{&xSummaryList{summaries}})
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
 		}
+		return doArchive(ctx, args, &archiveArgsA)
 	},
 }
 
+func doArchive(ctx *Context, args []string, config *archiveArgs) error {
+	if config.summaryArg {
+		summaries, err := inspectArchives(ctx, args, config)
+		if err != nil {
+			return err
+		}
+		if err := ctx.writeResult(&zipSummaryFormatter{&zipSummaryList{summaries}}); err != nil {
+			return err
+		}
+	} else if config.summaryXArg {
+		summaries, err := xSummary(ctx, args, config)
+		if err != nil {
+			return err
+		}
+		if len(summaries) == 0 {
+			return newCliError(EXIT_NOT_FOUND, "No documents to summarise")
+		}
+		if err := ctx.writeResult(&xSummaryFormatter{&xSummaryList{summaries}}); err != nil {
+			return err
+		}
+	}
+	return ctx.itemFailuresError()
+}
+
 type xSummaryList struct {
 	XSummaryList []*xmlDoc
 }
@@ -76,6 +100,14 @@ func (xs *xSummaryFormatter) ToJson() string {
 	return prettyMarshal(xs.xSummaries)
 }
 
+func (xs *xSummaryFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, summary := range xs.xSummaries.XSummaryList {
+		rc = append(rc, summary)
+	}
+	return rc
+}
+
 func (ds *xSummaryFormatter) ToQuiet() []identifiable {
 	rows := make([]identifiable, 0)
 	for _, res := range ds.xSummaries.XSummaryList {
@@ -110,6 +142,14 @@ func (zs *zipSummaryFormatter) ToJson() string {
 	return prettyMarshal(zs.results)
 }
 
+func (zs *zipSummaryFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, summary := range zs.results.SummaryList {
+		rc = append(rc, summary)
+	}
+	return rc
+}
+
 func (ds *zipSummaryFormatter) ToQuiet() []identifiable {
 	rows := make([]identifiable, 0)
 	for _, res := range ds.results.SummaryList {
@@ -120,26 +160,48 @@ func (ds *zipSummaryFormatter) ToQuiet() []identifiable {
 
 func (ds *zipSummaryFormatter) ToTable() *TableResult {
 	headers := []columnDef{columnDef{"file", 10}, columnDef{"Total Docs", 10},
-		columnDef{"minDate", 22}, columnDef{"maxDate", 22}, columnDef{"Authors", 50}}
+		columnDef{"minDate", 22}, columnDef{"maxDate", 22},
+		columnDef{"minModified", 22}, columnDef{"maxModified", 22}, columnDef{"Authors", 30},
+		columnDef{"Forms", 30}, columnDef{"Attachments", 11}, columnDef{"Attachment Size", 15},
+		columnDef{"Links", 6}}
 
 	rows := make([][]string, 0)
 	for _, res := range ds.results.SummaryList {
 		data := []string{res.FileName, strconv.Itoa(res.DocCount),
-			res.MinDate.Format(time.RFC3339), res.MaxDate.Format(time.RFC3339), strings.Join(res.Authors, ";")}
+			res.MinDate.Format(time.RFC3339), res.MaxDate.Format(time.RFC3339),
+			res.MinModified.Format(time.RFC3339), res.MaxModified.Format(time.RFC3339),
+			formatCounts(res.AuthorCounts), formatCounts(res.FormCounts),
+			strconv.Itoa(res.AttachmentCount), humanizeBytes(res.AttachmentSize),
+			strconv.Itoa(res.LinkCount)}
 		rows = append(rows, data)
 	}
 	return &TableResult{headers, rows}
 }
 
+// formats a map of counts as 'key:count' pairs, ordered by key
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s:%d", k, counts[k]))
+	}
+	return strings.Join(pairs, ";")
+}
+
 type archiveArgs struct {
 	summaryArg  bool
 	summaryXArg bool
 	manifestArg bool
+	parallel    int
 }
 
 var archiveArgsA archiveArgs
 
-func xSummary(args []string, config *archiveArgs) ([]*xmlDoc, error) {
+func xSummary(ctx *Context, args []string, config *archiveArgs) ([]*xmlDoc, error) {
 	if len(args) > 1 {
 		return nil, errors.New("Extended summary only available on a single archive file")
 	}
@@ -151,121 +213,182 @@ func xSummary(args []string, config *archiveArgs) ([]*xmlDoc, error) {
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Couldn't open the zip file %s", file))
 	}
-	parsedDocs := parseArchiveFiles(reader)
+	defer reader.Close()
+	parsedDocs := parseArchiveFiles(ctx, &reader.Reader)
 	return parsedDocs, nil
 }
 
-func inspectArchives(args []string, config *archiveArgs) ([]*zipSummary, error) {
-
-	zipSummaries := make([]*zipSummary, 0)
+// archiveResult holds the outcome of inspecting a single archive
+type archiveResult struct {
+	file        string
+	manifest    []byte
+	manifestErr error
+	summary     *zipSummary
+	err         error
+}
 
+// inspectArchives processes archives concurrently, up to config.parallel at a time.
+// Results are returned in the same order as the arguments.
+func inspectArchives(ctx *Context, args []string, config *archiveArgs) ([]*zipSummary, error) {
+	files := make([]string, 0)
 	for _, file := range args {
 		if filepath.Ext(file) != ".zip" {
-			messageStdErr(fmt.Sprintf("%s is not a zip file, skipping", file))
+			ctx.reportItemFailure(file, newCliError(EXIT_VALIDATION, "not a zip file, skipping"))
 			continue
 		}
+		files = append(files, file)
+	}
 
-		reader, err := zip.OpenReader(file)
-		if err != nil {
-			//messageStdErr(fmt.Sprintf("Could not open %s, skipping", file))
+	workers := config.parallel
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]*archiveResult, len(files))
+	sem := make(chan bool, workers)
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		sem <- true
+		go func(i int, file string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = inspectArchive(ctx, file, config)
+		}(i, file)
+	}
+	wg.Wait()
+
+	zipSummaries := make([]*zipSummary, 0)
+	for _, result := range results {
+		if result.err != nil {
+			ctx.reportItemFailure(result.file, result.err)
+			continue
 		}
-		if archiveArgsA.manifestArg {
-			messageStdErr(fmt.Sprintf("Manifest for %s:", file))
-			bytes, err := showManifest(reader)
-			if err != nil {
-				messageStdErr(err.Error())
+		if config.manifestArg {
+			ctx.messageStdErr(fmt.Sprintf("Manifest for %s:", result.file))
+			if result.manifestErr != nil {
+				ctx.messageStdErr(result.manifestErr.Error())
 			} else {
-				messageStdErr(string(bytes))
+				ctx.messageStdErr(string(result.manifest))
 			}
 		}
-		parsedDocs := parseArchiveFiles(reader)
-		if archiveArgsA.summaryArg {
-			//messageStdErr(fmt.Sprintf("Summary for %s:", file))
-			summary, err := summarise(parsedDocs)
-			if err != nil {
-				exitWithErr(err)
-			}
-			summary.FileName = filepath.Base(file)
-			zipSummaries = append(zipSummaries, summary)
+		if result.summary != nil {
+			zipSummaries = append(zipSummaries, result.summary)
 		}
 	}
 	return zipSummaries, nil
 }
 
-type xmlDoc struct {
-	XMLName          xml.Name
-	Name             string    `xml:"name"`
-	CreatedBy        string    `xml:"createdBy"`
-	CreationDate     time.Time `xml:"creationDate"`
-	LastModifiedDate time.Time `xml:"lastModifiedDate"`
-	Tags             string    `xml:"tag"`
+// inspectArchive reads the manifest and/or summary of a single archive, closing it when done.
+// It's called concurrently, so only reports failures through ctx.
+func inspectArchive(ctx *Context, file string, config *archiveArgs) *archiveResult {
+	result := &archiveResult{file: file}
+	reader, err := zip.OpenReader(file)
+	if err != nil {
+		result.err = errors.New(fmt.Sprintf("Couldn't open the zip file: %s", err.Error()))
+		return result
+	}
+	defer reader.Close()
+	if config.manifestArg {
+		result.manifest, result.manifestErr = showManifest(&reader.Reader)
+	}
+	if config.summaryArg {
+		summary, err := summarise(ctx, &reader.Reader)
+		if err != nil {
+			result.err = err
+			return result
+		}
+		summary.FileName = filepath.Base(file)
+		result.summary = summary
+	}
+	return result
 }
 
 func parseTimestamp(timestamp string) (time.Time, error) {
 	return time.Parse(time.RFC3339, timestamp)
 }
 
-func parseArchiveFiles(reader *zip.ReadCloser) []*xmlDoc {
-	parsedDocs := make([]*xmlDoc, 0)
-	for _, f := range reader.File {
-		fname := filename(f)
-		if strings.HasSuffix(fname, "xml") && strings.HasPrefix(fname, "doc") && !strings.HasSuffix(fname, "_form.xml") {
-			fc, _ := f.Open()
-			bytes, _ := ioutil.ReadAll(fc)
-			mydoc := xmlDoc{}
-			xml.Unmarshal(bytes, &mydoc)
-			parsedDocs = append(parsedDocs, &mydoc)
-		}
-	}
-	return parsedDocs
+type zipSummary struct {
+	DocCount        int
+	MinDate         time.Time
+	MaxDate         time.Time
+	Authors         []string
+	FileName        string
+	MinModified     time.Time
+	MaxModified     time.Time
+	AuthorCounts    map[string]int
+	FormCounts      map[string]int
+	AttachmentCount int
+	AttachmentSize  uint64
+	LinkCount       int
 }
 
-type zipSummary struct {
-	DocCount int
-	MinDate  time.Time
-	MaxDate  time.Time
-	Authors  []string
-	FileName string
+// summaryVisitor builds up a zipSummary as the archive content is streamed through it
+type summaryVisitor struct {
+	summary *zipSummary
 }
 
-func summarise(docs []*xmlDoc) (*zipSummary, error) {
-	if len(docs) == 0 {
-		err := errors.New("No documents to summarise")
-		return nil, err
-	}
+func newSummaryVisitor() *summaryVisitor {
+	return &summaryVisitor{&zipSummary{AuthorCounts: make(map[string]int),
+		FormCounts: make(map[string]int)}}
+}
 
-	maxDate := time.Time{}
-	minDate := docs[0].CreationDate
-	authors := make(map[string]bool, 0)
-	for _, v := range docs {
-		if v.CreationDate.After(maxDate) {
-			maxDate = v.CreationDate
-		}
-		if v.CreationDate.Before(minDate) {
-			minDate = v.CreationDate
-		}
-		authors[v.CreatedBy] = true
+func (v *summaryVisitor) visitDocument(doc *xmlDoc) error {
+	s := v.summary
+	if s.DocCount == 0 {
+		s.MinDate = doc.CreationDate
+		s.MinModified = doc.LastModifiedDate
 	}
-	var uniqueAuthors = make([]string, 0)
-	for k, _ := range authors {
-		uniqueAuthors = append(uniqueAuthors, k)
+	s.DocCount++
+	if doc.CreationDate.After(s.MaxDate) {
+		s.MaxDate = doc.CreationDate
+	}
+	if doc.CreationDate.Before(s.MinDate) {
+		s.MinDate = doc.CreationDate
+	}
+	if doc.LastModifiedDate.After(s.MaxModified) {
+		s.MaxModified = doc.LastModifiedDate
 	}
-	return &zipSummary{len(docs), minDate, maxDate, uniqueAuthors, ""}, nil
+	if doc.LastModifiedDate.Before(s.MinModified) {
+		s.MinModified = doc.LastModifiedDate
+	}
+	s.AuthorCounts[doc.CreatedBy]++
+	return nil
 }
 
-func filename(file *zip.File) string {
-	return filepath.Base(file.Name)
+func (v *summaryVisitor) visitForm(form *xmlForm) error {
+	v.summary.FormCounts[form.Name]++
+	return nil
 }
 
-func showManifest(reader *zip.ReadCloser) ([]byte, error) {
-	for _, f := range reader.File {
-		if filename(f) == "manifest.txt" {
-			fc, _ := f.Open()
-			bytes, _ := ioutil.ReadAll(fc)
-			return bytes, nil
-		}
+func (v *summaryVisitor) visitLinks(links *xmlLinkResolver) error {
+	v.summary.LinkCount += len(links.Links)
+	return nil
+}
+
+func (v *summaryVisitor) visitResource(file *zip.File) error {
+	v.summary.AttachmentCount++
+	v.summary.AttachmentSize += file.UncompressedSize64
+	return nil
+}
+
+// summarise streams through the archive, calculating a zipSummary
+func summarise(ctx *Context, reader *zip.Reader) (*zipSummary, error) {
+	visitor := newSummaryVisitor()
+	if err := walkArchive(ctx, reader, visitor); err != nil {
+		return nil, err
+	}
+	summary := visitor.summary
+	if summary.DocCount == 0 {
+		err := errors.New("No documents to summarise")
+		return nil, err
+	}
+	var uniqueAuthors = make([]string, 0)
+	for k := range summary.AuthorCounts {
+		uniqueAuthors = append(uniqueAuthors, k)
 	}
-	return nil, errors.New("No manifest.txt file found")
+	sort.Strings(uniqueAuthors)
+	summary.Authors = uniqueAuthors
+	return summary, nil
 }
 
 func init() {
@@ -274,6 +397,10 @@ func init() {
 	archiveCmd.Flags().BoolVar(&archiveArgsA.summaryArg, "summary", false, "Show summary of content")
 	archiveCmd.Flags().BoolVar(&archiveArgsA.summaryXArg, "xsummary", false, "Show Extended summary of content")
 	archiveCmd.Flags().BoolVar(&archiveArgsA.manifestArg, "manifest", true, "Shows manifest of the archive")
-	archiveCmd.Flags().StringVar(&outputFormatArg, "outputFormat", "table", "Output format: one of 'json','table', 'csv' or 'quiet' ")
+	archiveCmd.Flags().IntVar(&archiveArgsA.parallel, "parallel", 4, "Maximum number of archives to process at the same time")
+	archiveCmd.Flags().StringVar(&outputFormatArg, "outputFormat", "table", outputFormatHelp)
+	archiveCmd.Flags().StringVar(&columnsArg, "columns", "", "Comma-separated list of fields to show in table, csv or tsv output")
+	archiveCmd.Flags().StringVar(&templateArg, "template", "", "Go template applied to each result when using '-f template'")
+	archiveCmd.Flags().StringVar(&queryArg, "jmespath", "", "JMESPath expression applied to the JSON form of the results")
 	archiveCmd.Flags().StringVar(&outFileArg, "outFile", "", "Output file for program output")
 }
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type archiveFieldsArgs struct {
+	FormArg  string
+	HtmlFlag bool
+}
+
+var archiveFieldsArgsA archiveFieldsArgs
+
+var archiveFieldsCmd = &cobra.Command{
+	Use:   "fields",
+	Short: "Tabulates field values of structured documents in an XML archive",
+	Long: `Lists the field values of every document in an XML archive created from a given form,
+one row per document with a column for each field of the form.
+
+The form is specified by its id in the archive (e.g. 5 or FM5) or by its name.
+
+Field content is converted to plain text, unless --html is set.
+Use '-f csv' to load the results into a spreadsheet, pandas or R.
+	`,
+	Example: `
+// all documents created from form FM12, in CSV format
+rspace archive fields myArchive.zip --form FM12 -f csv > experiments.csv
+
+// choose the form by name, keeping any HTML formatting in field values
+rspace archive fields myArchive.zip --form "PCR setup" --html -f json
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseLocalContext()
+		if err != nil {
+			return err
+		}
+		return doArchiveFields(ctx, args[0], &archiveFieldsArgsA)
+	},
+}
+
+// fieldsRow holds the field values of a single document, in form field order
+type fieldsRow struct {
+	DocId        int
+	Name         string
+	CreatedBy    string
+	CreationDate time.Time
+	Fields       []fieldValue
+}
+
+type fieldValue struct {
+	Name  string
+	Value string
+}
+
+type fieldsTable struct {
+	FormId   int
+	FormName string
+	Rows     []*fieldsRow
+}
+
+type fieldsTableFormatter struct {
+	*fieldsTable
+}
+
+func (fs *fieldsTableFormatter) ToJson() string {
+	return prettyMarshal(fs.fieldsTable)
+}
+
+func (fs *fieldsTableFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, row := range fs.Rows {
+		rc = append(rc, row)
+	}
+	return rc
+}
+
+func (fs *fieldsTableFormatter) ToQuiet() []identifiable {
+	rows := make([]identifiable, 0)
+	for _, res := range fs.Rows {
+		rows = append(rows, identifiable{strconv.Itoa(res.DocId)})
+	}
+	return rows
+}
+
+func (fs *fieldsTableFormatter) ToTable() *TableResult {
+	headers := []columnDef{columnDef{"DocId", 8}, columnDef{"Name", 25},
+		columnDef{"Owner", 12}, columnDef{"Created", DISPLAY_TIMESTAMP_WIDTH}}
+	if len(fs.Rows) > 0 {
+		for _, field := range fs.Rows[0].Fields {
+			headers = append(headers, columnDef{field.Name, 20})
+		}
+	}
+
+	rows := make([][]string, 0)
+	for _, res := range fs.Rows {
+		data := []string{strconv.Itoa(res.DocId), res.Name, res.CreatedBy,
+			res.CreationDate.Format(time.RFC3339)[0:DISPLAY_TIMESTAMP_WIDTH]}
+		for _, field := range res.Fields {
+			data = append(data, field.Value)
+		}
+		rows = append(rows, data)
+	}
+	return &TableResult{headers, rows}
+}
+
+func doArchiveFields(ctx *Context, file string, args *archiveFieldsArgs) error {
+	if len(args.FormArg) == 0 {
+		return errors.New("Please specify a form id or name using --form")
+	}
+	reader, err := zip.OpenReader(file)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Couldn't open the zip file %s", file))
+	}
+	defer reader.Close()
+	if isHtmlExport(&reader.Reader) {
+		return errors.New("Field values can only be read from XML archives")
+	}
+	table, err := tabulateFields(&reader.Reader, args)
+	if err != nil {
+		return err
+	}
+	if len(table.Rows) == 0 {
+		return errors.New(fmt.Sprintf("No documents created from form '%s' in %s", args.FormArg, file))
+	}
+	return ctx.writeResult(&fieldsTableFormatter{table})
+}
+
+// tabulateFields reads each matching document in turn, keeping only its field values
+func tabulateFields(reader *zip.Reader, args *archiveFieldsArgs) (*fieldsTable, error) {
+	formId, _ := idFromGlobalId(args.FormArg)
+	table := &fieldsTable{Rows: make([]*fieldsRow, 0)}
+	for _, f := range reader.File {
+		if !isDocFile(filename(f)) {
+			continue
+		}
+		form, err := formForDoc(reader, f)
+		if err != nil || !formMatches(form, formId, args.FormArg) {
+			continue
+		}
+		doc := archivalDocument{}
+		if err := decodeZipFile(f, &doc); err != nil {
+			messageStdErr(fmt.Sprintf("Could not parse %s, skipping: %s", filename(f), err.Error()))
+			continue
+		}
+		row := &fieldsRow{DocId: doc.DocId, Name: doc.Name, CreatedBy: doc.CreatedBy,
+			CreationDate: doc.CreationDate, Fields: make([]fieldValue, 0)}
+		for _, field := range doc.Fields {
+			value := field.Data
+			if !args.HtmlFlag {
+				value = htmlToText(value)
+			}
+			row.Fields = append(row.Fields, fieldValue{field.Name, value})
+		}
+		if len(table.Rows) > 0 && len(row.Fields) != len(table.Rows[0].Fields) {
+			return nil, errors.New(fmt.Sprintf("Document %d has %d fields but expected %d - are there several versions of the form?",
+				doc.DocId, len(row.Fields), len(table.Rows[0].Fields)))
+		}
+		table.FormId = form.Id
+		table.FormName = form.Name
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+// matches by id if formId > 0, else by name
+func formMatches(form *xmlForm, formId int, formArg string) bool {
+	if formId > 0 {
+		return form.Id == formId
+	}
+	return form.Name == formArg
+}
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// removes HTML tags and entities, and normalises whitespace
+func htmlToText(content string) string {
+	text := htmlTagRegexp.ReplaceAllString(content, " ")
+	text = html.UnescapeString(text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func init() {
+	archiveCmd.AddCommand(archiveFieldsCmd)
+	archiveFieldsCmd.Flags().StringVar(&archiveFieldsArgsA.FormArg, "form", "", "Id or name of the form, as it appears in the archive")
+	archiveFieldsCmd.Flags().BoolVar(&archiveFieldsArgsA.HtmlFlag, "html", false, "Keep HTML formatting of field values")
+	archiveFieldsCmd.Flags().StringVarP(&outputFormatArg, "outputFormat", "f", "table", outputFormatHelp)
+	archiveFieldsCmd.Flags().StringVar(&columnsArg, "columns", "", "Comma-separated list of fields to show in table, csv or tsv output")
+	archiveFieldsCmd.Flags().StringVar(&templateArg, "template", "", "Go template applied to each result when using '-f template'")
+	archiveFieldsCmd.Flags().StringVar(&queryArg, "jmespath", "", "JMESPath expression applied to the JSON form of the results")
+	archiveFieldsCmd.Flags().StringVar(&outFileArg, "outFile", "", "Output file for program output")
+}
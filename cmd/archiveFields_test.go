@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestArchiveFields(t *testing.T) {
+	outWriter := bytes.NewBufferString("")
+	ctx := &Context{Writer: outWriter, Format: outputFmt("csv")}
+	err := doArchiveFields(ctx, "testData/rs-import.zip", &archiveFieldsArgs{FormArg: "FM7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(outWriter.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected header and 1 row but got %v", lines)
+	}
+	assertEqualString(t, "DocId,Name,Owner,Created,Date,Method", lines[0])
+	assertEqualString(t, "3002,PCR run 1,user5e,2020-05-20T13:55,2020-05-20,standard", lines[1])
+}
+
+func TestArchiveFieldsByFormName(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("csv")}
+	err := doArchiveFields(ctx, "testData/rs-import.zip", &archiveFieldsArgs{FormArg: "PCR setup", HtmlFlag: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ctx.Writer.(*bytes.Buffer).String(), "<p>standard</p>") {
+		t.Fatalf("Expected HTML to be kept")
+	}
+	err = doArchiveFields(ctx, "testData/rs-import.zip", &archiveFieldsArgs{FormArg: "unknown form"})
+	if err == nil {
+		t.Fatalf("Should fail - no documents from this form")
+	}
+}
+
+func TestHtmlToText(t *testing.T) {
+	assertEqualString(t, "a & b c", htmlToText("<p>a &amp; b</p>\n<p>c</p>"))
+}
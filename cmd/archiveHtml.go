@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// HTML exports have one page per document, at 'Name-ID/Name-ID.html', plus an 'index.html' listing the
+// contents. Document metadata is read from the metadata table at the top of each page, e.g.
+//
+//	<table class="metadata"><tr><th>Owner:</th><td>user5 user5 (user5e)</td></tr> ... </table>
+//
+// Tables in the document's fields are never read as metadata. Unrecognised rows are ignored.
+// Pages without a metadata table are still read, named after their <title>, with a warning.
+var htmlMetadataLabels = map[string]string{
+	"name":          "name",
+	"owner":         "owner",
+	"created":       "created",
+	"last modified": "modified",
+	"tags":          "tags",
+	"form":          "form",
+}
+
+// layouts tried, in order, to parse dates in HTML exports
+var htmlDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04",
+	"2006-01-02", "Jan 2, 2006 15:04", "Jan 2, 2006"}
+
+// matches an owner of the form 'Full Name (username)'
+var ownerUsernameRegexp = regexp.MustCompile(`\(([^()]+)\)\s*$`)
+
+// isHtmlExport is true if the archive has HTML pages but no XML documents
+func isHtmlExport(reader *zip.Reader) bool {
+	hasHtml := false
+	for _, f := range reader.File {
+		fname := filename(f)
+		if isDocFile(fname) {
+			return false
+		}
+		if isHtmlDocFile(f) {
+			hasHtml = true
+		}
+	}
+	return hasHtml
+}
+
+// isHtmlDocFile is true for document pages, which are named after the folder they are in
+func isHtmlDocFile(file *zip.File) bool {
+	name := path.Base(file.Name)
+	ext := path.Ext(name)
+	if strings.ToLower(ext) != ".html" || file.FileInfo().IsDir() || isResource(file) {
+		return false
+	}
+	return strings.TrimSuffix(name, ext) == path.Base(path.Dir(file.Name))
+}
+
+// hasHtmlClass is true if an element's class attribute includes a class name
+func hasHtmlClass(element html.Token, class string) bool {
+	for _, attr := range element.Attr {
+		if attr.Key == "class" {
+			for _, c := range strings.Fields(attr.Val) {
+				if strings.EqualFold(c, class) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// walkHtmlArchive is the HTML-export equivalent of walkArchive. HTML exports have no form
+// definitions or link map, so forms are taken from each page's metadata.
+func walkHtmlArchive(ctx *Context, reader *zip.Reader, visitor archiveVisitor) error {
+	for _, f := range reader.File {
+		var err error
+		if isHtmlDocFile(f) {
+			page, decodeErr := decodeHtmlZipFile(f)
+			if decodeErr != nil {
+				reportUnparsableFile(ctx, f, decodeErr)
+				continue
+			}
+			if !page.hasMetadata {
+				ctx.messageStdErr(fmt.Sprintf("Warning: %s has no metadata table, so only its name is known, from its title", filename(f)))
+			}
+			if err = visitor.visitDocument(page.doc); err == nil && len(page.formName) > 0 {
+				err = visitor.visitForm(&xmlForm{Name: page.formName})
+			}
+		} else if !f.FileInfo().IsDir() && isResource(f) {
+			err = visitor.visitResource(f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeHtmlZipFile(file *zip.File) (*htmlPage, error) {
+	fc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fc.Close()
+	return parseHtmlDoc(fc)
+}
+
+// htmlPage is the metadata read from an HTML export page
+type htmlPage struct {
+	doc *xmlDoc
+	// the name of the document's form, if known
+	formName string
+	// false if no metadata table was found
+	hasMetadata bool
+}
+
+// parseHtmlDoc reads document metadata from an HTML export page. If the page has no 'Name' row,
+// the page title is used. Metadata is read from the table with class 'metadata', or else the
+// first table before any field.
+func parseHtmlDoc(r io.Reader) (*htmlPage, error) {
+	z := html.NewTokenizer(r)
+	title := ""
+	inTitle := false
+	var cell *strings.Builder
+	row := make([]string, 0)
+	// the tables being read, innermost last
+	tables := make([]*htmlTable, 0)
+	var metadataRows, firstTableRows [][]string
+	inFields := false
+	for {
+		tokenType := z.Next()
+		if tokenType == html.ErrorToken {
+			if z.Err() == io.EOF {
+				break
+			}
+			return nil, z.Err()
+		}
+		token := z.Token()
+		switch tokenType {
+		case html.StartTagToken:
+			switch token.Data {
+			case "title":
+				inTitle = true
+			case "table":
+				tables = append(tables, &htmlTable{isMetadata: hasHtmlClass(token, "metadata"), beforeFields: !inFields})
+			case "tr":
+				row = make([]string, 0)
+			case "td", "th":
+				cell = &strings.Builder{}
+			}
+			if hasHtmlClass(token, "field") {
+				inFields = true
+			}
+		case html.TextToken:
+			if inTitle {
+				title += token.Data
+			} else if cell != nil {
+				cell.WriteString(token.Data)
+			}
+		case html.EndTagToken:
+			switch token.Data {
+			case "title":
+				inTitle = false
+			case "td", "th":
+				if cell != nil {
+					row = append(row, strings.Join(strings.Fields(cell.String()), " "))
+					cell = nil
+				}
+			case "tr":
+				if len(row) >= 2 && len(tables) > 0 {
+					table := tables[len(tables)-1]
+					table.rows = append(table.rows, row)
+				}
+				row = make([]string, 0)
+			case "table":
+				if len(tables) == 0 {
+					continue
+				}
+				table := tables[len(tables)-1]
+				tables = tables[:len(tables)-1]
+				if table.isMetadata && metadataRows == nil {
+					metadataRows = table.rows
+				} else if table.beforeFields && len(tables) == 0 && firstTableRows == nil {
+					firstTableRows = table.rows
+				}
+			}
+		}
+	}
+	if metadataRows == nil {
+		metadataRows = firstTableRows
+	}
+	page := &htmlPage{doc: &xmlDoc{}}
+	for _, row := range metadataRows {
+		if setHtmlMetadata(page, row[0], row[1]) {
+			page.hasMetadata = true
+		}
+	}
+	if len(page.doc.Name) == 0 {
+		page.doc.Name = strings.TrimSpace(title)
+	}
+	return page, nil
+}
+
+// a table in an HTML export page, and the rows of 2 or more cells in it
+type htmlTable struct {
+	rows         [][]string
+	isMetadata   bool
+	beforeFields bool
+}
+
+// sets the value into the page, returning false if label isn't a known metadata label
+func setHtmlMetadata(page *htmlPage, label, value string) bool {
+	doc := page.doc
+	label = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(label), ":"))
+	switch htmlMetadataLabels[label] {
+	case "name":
+		doc.Name = value
+	case "owner":
+		if match := ownerUsernameRegexp.FindStringSubmatch(value); match != nil {
+			value = match[1]
+		}
+		doc.CreatedBy = value
+	case "created":
+		doc.CreationDate = parseHtmlDate(value)
+	case "modified":
+		doc.LastModifiedDate = parseHtmlDate(value)
+	case "tags":
+		doc.Tags = value
+	case "form":
+		page.formName = value
+	default:
+		return false
+	}
+	return true
+}
+
+// returns zero time if the date can't be parsed
+func parseHtmlDate(value string) time.Time {
+	for _, layout := range htmlDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
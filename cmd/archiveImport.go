@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+	"github.com/spf13/cobra"
+)
+
+const BASIC_DOCUMENT_FORM = "Basic Document"
+
+type archiveImportArgs struct {
+	FolderArg  string
+	FormMapArg string
+	DryRun     bool
+}
+
+var archiveImportArgsA archiveImportArgs
+
+var archiveImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Re-creates documents from an XML archive in an RSpace folder",
+	Long: `Reads documents from an XML archive and creates new copies of them in RSpace,
+in the folder given by --folder. This is useful to migrate content between RSpace servers.
+
+Attachments, images, audio and video files are uploaded to the Gallery, and links to them in the
+document content are replaced with links to the newly uploaded files.
+Links to other documents, comments, sketches and annotations are not re-created.
+
+Basic documents are created as Basic documents. Structured documents need a form on the target server
+to be created from; use --formMap to say which form to use for each form in the archive, as a
+comma-separated list of 'archiveFormId=targetFormId' pairs. Form ids in the archive are listed by --dry-run.
+
+The result is a report of old and new ids of each document.
+	`,
+	Example: `
+// check what would be imported, without making any changes
+rspace archive import myArchive.zip --folder FL123 --dry-run
+
+// import into folder FL123
+rspace archive import myArchive.zip --folder FL123
+
+// import structured documents created from form 5 in the archive using form FM12 on this server
+rspace archive import myArchive.zip --folder FL123 --formMap 5=FM12 -f csv > mapping.csv
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doArchiveImport(ctx, ctx.WebClient, args[0], &archiveImportArgsA)
+	},
+}
+
+// ArchiveImportClient creates documents and uploads their attachments
+type ArchiveImportClient interface {
+	DocClient
+	FileUploadClient
+}
+
+// importMapping records the outcome of importing a single document
+type importMapping struct {
+	OldId       int
+	Name        string
+	FormId      int
+	FormName    string
+	NewId       int
+	NewGlobalId string
+	Attachments int
+	Error       string
+}
+
+type importMappingList struct {
+	Mappings []*importMapping
+}
+
+type importMappingFormatter struct {
+	*importMappingList
+}
+
+func (fs *importMappingFormatter) ToJson() string {
+	return prettyMarshal(fs.importMappingList)
+}
+
+func (fs *importMappingFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, mapping := range fs.Mappings {
+		rc = append(rc, mapping)
+	}
+	return rc
+}
+
+func (fs *importMappingFormatter) ToQuiet() []identifiable {
+	rows := make([]identifiable, 0)
+	for _, res := range fs.Mappings {
+		if res.NewId > 0 {
+			rows = append(rows, identifiable{strconv.Itoa(res.NewId)})
+		}
+	}
+	return rows
+}
+
+func (fs *importMappingFormatter) ToTable() *TableResult {
+	headers := []columnDef{columnDef{"OldId", 8}, columnDef{"Name", 25}, columnDef{"Form", 20},
+		columnDef{"NewGlobalId", 12}, columnDef{"Attachments", 11}, columnDef{"Error", 40}}
+
+	rows := make([][]string, 0)
+	for _, res := range fs.Mappings {
+		data := []string{strconv.Itoa(res.OldId), res.Name,
+			fmt.Sprintf("%s (%d)", res.FormName, res.FormId), res.NewGlobalId,
+			strconv.Itoa(res.Attachments), res.Error}
+		rows = append(rows, data)
+	}
+	return &TableResult{headers, rows}
+}
+
+func doArchiveImport(ctx *Context, client ArchiveImportClient, file string, args *archiveImportArgs) error {
+	folderId, err := idFromGlobalId(args.FolderArg)
+	if err != nil || folderId == 0 {
+		return errors.New("Please supply a target folder id using --folder")
+	}
+	formMap, err := parseFormMap(args.FormMapArg)
+	if err != nil {
+		return err
+	}
+	reader, err := zip.OpenReader(file)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Couldn't open the zip file %s", file))
+	}
+	defer reader.Close()
+	if isHtmlExport(&reader.Reader) {
+		return errors.New("Only XML archives can be imported")
+	}
+	tmpDir, err := ioutil.TempDir("", "rspace-import")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	importer := &archiveImporter{client, &reader.Reader, folderId, formMap, args.DryRun, tmpDir, make(map[string]int)}
+	mappings := make([]*importMapping, 0)
+	for _, f := range reader.File {
+		if !isDocFile(filename(f)) {
+			continue
+		}
+		mapping := importer.importDocument(f)
+		if len(mapping.Error) > 0 {
+			ctx.reportItemFailure(filename(f), errors.New(mapping.Error))
+		}
+		mappings = append(mappings, mapping)
+	}
+	if err := ctx.writeResult(&importMappingFormatter{&importMappingList{mappings}}); err != nil {
+		return err
+	}
+	return ctx.itemFailuresError()
+}
+
+// parses 'archiveFormId=targetFormId' pairs
+func parseFormMap(formMapArg string) (map[int]int, error) {
+	rc := make(map[int]int)
+	if len(formMapArg) == 0 {
+		return rc, nil
+	}
+	for _, pair := range strings.Split(formMapArg, ",") {
+		ids := strings.Split(strings.TrimSpace(pair), "=")
+		if len(ids) != 2 {
+			return nil, errors.New(fmt.Sprintf("Invalid form mapping '%s', should be 'archiveFormId=targetFormId'", pair))
+		}
+		oldId, err1 := idFromGlobalId(ids[0])
+		newId, err2 := idFromGlobalId(ids[1])
+		if err1 != nil || err2 != nil || oldId == 0 || newId == 0 {
+			return nil, errors.New(fmt.Sprintf("Invalid form mapping '%s', should be 'archiveFormId=targetFormId'", pair))
+		}
+		rc[oldId] = newId
+	}
+	return rc, nil
+}
+
+type archiveImporter struct {
+	client   ArchiveImportClient
+	reader   *zip.Reader
+	folderId int
+	formMap  map[int]int
+	dryRun   bool
+	tmpDir   string
+	// ids of files already uploaded, by their path in the archive
+	uploadedIds map[string]int
+}
+
+func (imp *archiveImporter) importDocument(f *zip.File) *importMapping {
+	mapping := &importMapping{}
+	doc := archivalDocument{}
+	if err := decodeZipFile(f, &doc); err != nil {
+		mapping.Error = err.Error()
+		return mapping
+	}
+	mapping.OldId = doc.DocId
+	mapping.Name = doc.Name
+
+	form, err := formForDoc(imp.reader, f)
+	if err != nil {
+		mapping.Error = err.Error()
+		return mapping
+	}
+	mapping.FormId = form.Id
+	mapping.FormName = form.Name
+
+	post := rspace.DocumentPost{Name: doc.Name, Tags: doc.Tags, ParentFolderId: imp.folderId}
+	if form.Name != BASIC_DOCUMENT_FORM {
+		targetFormId, ok := imp.formMap[form.Id]
+		if !ok {
+			mapping.Error = fmt.Sprintf("No target form for form '%s' (%d), use --formMap", form.Name, form.Id)
+			return mapping
+		}
+		post.FormID = rspace.FormId{targetFormId}
+	}
+	docDir := path.Dir(f.Name)
+	fields := make([]rspace.FieldContent, 0)
+	for _, field := range doc.Fields {
+		content := field.Data
+		for _, item := range field.galleryItems() {
+			content, err = imp.importAttachment(docDir, item, content)
+			if err != nil {
+				mapping.Error = err.Error()
+				return mapping
+			}
+			mapping.Attachments++
+		}
+		fields = append(fields, rspace.FieldContent{Content: content})
+	}
+	post.Fields = fields
+	if imp.dryRun {
+		return mapping
+	}
+	created, err := imp.client.NewDocumentWithContent(&post)
+	if err != nil {
+		mapping.Error = err.Error()
+		return mapping
+	}
+	mapping.NewId = created.Id
+	mapping.NewGlobalId = created.GlobalId
+	return mapping
+}
+
+// uploads an archived file to the Gallery, returning content with links to the file replaced.
+func (imp *archiveImporter) importAttachment(docDir string, item archivalGalleryItem, content string) (string, error) {
+	archived := imp.findArchivedFile(docDir, item.LinkFile)
+	if archived == nil {
+		return "", errors.New(fmt.Sprintf("Attachment %s not found in archive", item.LinkFile))
+	}
+	if imp.dryRun {
+		return content, nil
+	}
+	if id, ok := imp.uploadedIds[archived.Name]; ok {
+		return rewriteAttachmentLinks(content, item.LinkFile, id), nil
+	}
+	name := item.FileName
+	if len(name) == 0 {
+		name = filename(archived)
+	}
+	localPath, err := extractToDir(archived, imp.tmpDir, name)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(localPath)
+	uploaded, err := imp.client.UploadFile(rspace.FileUploadConfig{FilePath: localPath})
+	if err != nil {
+		return "", err
+	}
+	imp.uploadedIds[archived.Name] = uploaded.Id
+	return rewriteAttachmentLinks(content, item.LinkFile, uploaded.Id), nil
+}
+
+// looks for the file relative to the document folder, then anywhere in the archive
+func (imp *archiveImporter) findArchivedFile(docDir, linkFile string) *zip.File {
+	if len(linkFile) == 0 {
+		return nil
+	}
+	var byName *zip.File
+	for _, f := range imp.reader.File {
+		if f.Name == path.Join(docDir, linkFile) {
+			return f
+		}
+		if byName == nil && filename(f) == path.Base(linkFile) {
+			byName = f
+		}
+	}
+	return byName
+}
+
+func extractToDir(file *zip.File, dir, name string) (string, error) {
+	fc, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer fc.Close()
+	localPath := filepath.Join(dir, filepath.Base(name))
+	out, err := os.Create(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, fc)
+	return localPath, err
+}
+
+// replaces image tags or hyperlinks to the archived file with a link to the uploaded file.
+// If the content has no link to the file, a link is appended.
+func rewriteAttachmentLinks(content, linkFile string, newFileId int) string {
+	name := regexp.QuoteMeta(path.Base(linkFile))
+	// the file name must be the whole of the last path segment, so 'a.png' doesn't match 'data.png'
+	imgRegexp := regexp.MustCompile(`<img[^>]*\ssrc="([^"]*/)?` + name + `"[^>]*>`)
+	linkRegexp := regexp.MustCompile(`(?s)<a[^>]*\shref="([^"]*/)?` + name + `"[^>]*>.*?</a>`)
+	newLink := fmt.Sprintf("<fileId=%d>", newFileId)
+	rewritten := imgRegexp.ReplaceAllLiteralString(content, newLink)
+	rewritten = linkRegexp.ReplaceAllLiteralString(rewritten, newLink)
+	if rewritten == content {
+		rewritten = content + newLink
+	}
+	return rewritten
+}
+
+func init() {
+	archiveCmd.AddCommand(archiveImportCmd)
+	archiveImportCmd.Flags().StringVar(&archiveImportArgsA.FolderArg, "folder", "", "Id of the folder to import documents into")
+	archiveImportCmd.Flags().StringVar(&archiveImportArgsA.FormMapArg, "formMap", "",
+		"Comma-separated list of archiveFormId=targetFormId pairs, to create structured documents")
+	archiveImportCmd.Flags().BoolVar(&archiveImportArgsA.DryRun, "dry-run", false, "Reports what would be imported, without creating anything")
+	archiveImportCmd.Flags().StringVarP(&outputFormatArg, "outputFormat", "f", "table", outputFormatHelp)
+	archiveImportCmd.Flags().StringVar(&columnsArg, "columns", "", "Comma-separated list of fields to show in table, csv or tsv output")
+	archiveImportCmd.Flags().StringVar(&templateArg, "template", "", "Go template applied to each result when using '-f template'")
+	archiveImportCmd.Flags().StringVar(&queryArg, "jmespath", "", "JMESPath expression applied to the JSON form of the results")
+	archiveImportCmd.Flags().StringVar(&outFileArg, "outFile", "", "Output file for program output")
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+// records documents created and files uploaded
+type ImportClientSpy struct {
+	posts     []*rspace.DocumentPost
+	uploaded  []string
+	idCounter int
+}
+
+func (spy *ImportClientSpy) nextId() int {
+	spy.idCounter++
+	return 5000 + spy.idCounter
+}
+
+func (spy *ImportClientSpy) NewBasicDocumentWithContent(name, tags, content string) (*rspace.Document, error) {
+	return spy.NewDocumentWithContent(&rspace.DocumentPost{Name: name, Tags: tags})
+}
+
+func (spy *ImportClientSpy) NewDocumentWithContent(post *rspace.DocumentPost) (*rspace.Document, error) {
+	spy.posts = append(spy.posts, post)
+	id := spy.nextId()
+	namable := &rspace.IdentifiableNamable{Id: id, GlobalId: "SD" + strconv.Itoa(id), Name: post.Name}
+	return &rspace.Document{DocumentInfo: &rspace.DocumentInfo{IdentifiableNamable: namable}}, nil
+}
+
+func (spy *ImportClientSpy) UploadFile(config rspace.FileUploadConfig) (*rspace.FileInfo, error) {
+	spy.uploaded = append(spy.uploaded, config.FilePath)
+	namable := &rspace.IdentifiableNamable{Id: spy.nextId()}
+	return &rspace.FileInfo{IdentifiableNamable: namable}, nil
+}
+
+func TestArchiveImport(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("json")}
+	spy := &ImportClientSpy{}
+	args := &archiveImportArgs{FolderArg: "FL123", FormMapArg: "5=FM1, 7=FM12"}
+	err := doArchiveImport(ctx, spy, "testData/rs-import.zip", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spy.posts) != 2 {
+		t.Fatalf("Expected 2 documents created but got %d", len(spy.posts))
+	}
+	if len(spy.uploaded) != 1 || !strings.HasSuffix(spy.uploaded[0], "gel.png") {
+		t.Fatalf("Expected gel.png to be uploaded but got %v", spy.uploaded)
+	}
+	basic := spy.posts[0]
+	if basic.ParentFolderId != 123 || basic.FormID.Id != 0 || basic.Tags != "gel,pcr" {
+		t.Fatalf("Unexpected basic document post %+v", basic)
+	}
+	// image tag replaced by link to new file
+	if !strings.Contains(basic.Fields[0].Content, "<fileId=5001>") ||
+		strings.Contains(basic.Fields[0].Content, "gel_3005.png") {
+		t.Fatalf("Attachment link not rewritten: %s", basic.Fields[0].Content)
+	}
+	structured := spy.posts[1]
+	if structured.FormID.Id != 12 || len(structured.Fields) != 2 || structured.Fields[0].Content != "2020-05-20" {
+		t.Fatalf("Unexpected structured document post %+v", structured)
+	}
+	if !strings.Contains(ctx.Writer.(*bytes.Buffer).String(), `"NewGlobalId": "SD5003"`) {
+		t.Fatalf("Expected mapping report to include new id")
+	}
+}
+
+func TestArchiveImportDryRunAndUnmappedForm(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), ErrWriter: bytes.NewBufferString(""), Format: outputFmt("csv")}
+	spy := &ImportClientSpy{}
+	args := &archiveImportArgs{FolderArg: "123", DryRun: true}
+	err := doArchiveImport(ctx, spy, "testData/rs-import.zip", args)
+	// the unmapped form is a partial failure
+	if err == nil || exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Fatalf("Expected partial failure but got %v", err)
+	}
+	if len(spy.posts) != 0 || len(spy.uploaded) != 0 {
+		t.Fatalf("Dry run should not create anything")
+	}
+	report := ctx.Writer.(*bytes.Buffer).String()
+	if !strings.Contains(report, "No target form for form 'PCR setup' (7)") {
+		t.Fatalf("Expected error for unmapped form in report but got %s", report)
+	}
+}
+
+func TestArchiveImportRequiresFolder(t *testing.T) {
+	err := doArchiveImport(&Context{}, &ImportClientSpy{}, "testData/rs-import.zip", &archiveImportArgs{})
+	if err == nil {
+		t.Fatalf("Should fail - no target folder")
+	}
+}
+
+func TestParseFormMap(t *testing.T) {
+	formMap, err := parseFormMap("5=FM1,FM7=12")
+	if err != nil || formMap[5] != 1 || formMap[7] != 12 {
+		t.Fatalf("Unexpected form map %v", formMap)
+	}
+	if _, err = parseFormMap("5:FM1"); err == nil {
+		t.Fatalf("Should fail - invalid separator")
+	}
+}
+
+func TestRewriteAttachmentLinks(t *testing.T) {
+	content := `<p>see <a href="../doc_x/report_12.pdf">report</a></p>`
+	rewritten := rewriteAttachmentLinks(content, "report_12.pdf", 99)
+	assertEqualString(t, "<p>see <fileId=99></p>", rewritten)
+	// appended if not linked in the content
+	assertEqualString(t, "<p>text</p><fileId=99>", rewriteAttachmentLinks("<p>text</p>", "report_12.pdf", 99))
+	// only links to the whole file name are rewritten
+	content = `<img src="data.png"><img src="img/a.png"><img src="a.png">`
+	assertEqualString(t, `<img src="data.png"><fileId=7><fileId=7>`, rewriteAttachmentLinks(content, "a.png", 7))
+}
+
+func TestArchiveImportUploadsEachAttachmentOnce(t *testing.T) {
+	reader, err := zip.OpenReader("testData/rs-import.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	tmpDir, _ := ioutil.TempDir("", "import")
+	defer os.RemoveAll(tmpDir)
+	spy := &ImportClientSpy{}
+	imp := &archiveImporter{client: spy, reader: &reader.Reader, tmpDir: tmpDir, uploadedIds: make(map[string]int)}
+	docDir := "RSpace-2020-05-20-14-02-xml-SELECTION-Xq3kVn0rTb1c2A/doc_Gel-image-3001"
+	item := archivalGalleryItem{Id: 3005, FileName: "gel.png", LinkFile: "gel_3005.png"}
+	first, _ := imp.importAttachment(docDir, item, `<img src="gel_3005.png">`)
+	second, err := imp.importAttachment(docDir, item, `<p>again <img src="gel_3005.png"></p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spy.uploaded) != 1 {
+		t.Fatalf("Expected gel.png to be uploaded once but got %v", spy.uploaded)
+	}
+	assertEqualString(t, "<fileId=5001>", first)
+	assertEqualString(t, "<p>again <fileId=5001></p>", second)
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type xmlDoc struct {
+	XMLName          xml.Name
+	Name             string    `xml:"name"`
+	CreatedBy        string    `xml:"createdBy"`
+	CreationDate     time.Time `xml:"creationDate"`
+	LastModifiedDate time.Time `xml:"lastModifiedDate"`
+	Tags             string    `xml:"tag"`
+}
+
+// xmlForm is the form definition stored alongside each document, in a '_form.xml' file
+type xmlForm struct {
+	XMLName xml.Name
+	Id      int    `xml:"id,attr"`
+	Name    string `xml:"name"`
+}
+
+// xmlLinkResolver is the map of links between documents, stored in 'linkResolver.xml'
+type xmlLinkResolver struct {
+	XMLName xml.Name
+	Links   []struct {
+		Key   string `xml:"key"`
+		Value string `xml:"value"`
+	} `xml:"linkMap>entry"`
+}
+
+// archivalDocument is the full content of a document in an XML archive
+type archivalDocument struct {
+	XMLName          xml.Name
+	DocId            int             `xml:"docId,attr"`
+	Name             string          `xml:"name"`
+	CreatedBy        string          `xml:"createdBy"`
+	CreationDate     time.Time       `xml:"creationDate"`
+	LastModifiedDate time.Time       `xml:"lastModifiedDate"`
+	Tags             string          `xml:"tag"`
+	Fields           []archivalField `xml:"listFields>field"`
+}
+
+type archivalField struct {
+	Id          int                   `xml:"id,attr"`
+	Name        string                `xml:"fieldName"`
+	Type        string                `xml:"fieldType"`
+	Data        string                `xml:"fieldData"`
+	Images      []archivalGalleryItem `xml:"imageList>image-Info"`
+	Attachments []archivalGalleryItem `xml:"attachList>attach-info"`
+	Audio       []archivalGalleryItem `xml:"audioList>audio-Info"`
+	Video       []archivalGalleryItem `xml:"videoList>video-info"`
+}
+
+// files uploadable to the Gallery
+func (field *archivalField) galleryItems() []archivalGalleryItem {
+	rc := make([]archivalGalleryItem, 0)
+	rc = append(rc, field.Images...)
+	rc = append(rc, field.Attachments...)
+	rc = append(rc, field.Audio...)
+	return append(rc, field.Video...)
+}
+
+type archivalGalleryItem struct {
+	Id       int    `xml:"id,attr"`
+	FileName string `xml:"fileName"`
+	LinkFile string `xml:"linkFile"`
+}
+
+// archiveVisitor receives the content of an archive one item at a time, so that
+// an archive can be processed without holding all its documents in memory.
+// Returning an error stops the walk.
+type archiveVisitor interface {
+	visitDocument(doc *xmlDoc) error
+	visitForm(form *xmlForm) error
+	visitLinks(links *xmlLinkResolver) error
+	visitResource(file *zip.File) error
+}
+
+// baseArchiveVisitor ignores everything; embed it to handle only some archive content
+type baseArchiveVisitor struct{}
+
+func (v *baseArchiveVisitor) visitDocument(doc *xmlDoc) error         { return nil }
+func (v *baseArchiveVisitor) visitForm(form *xmlForm) error           { return nil }
+func (v *baseArchiveVisitor) visitLinks(links *xmlLinkResolver) error { return nil }
+func (v *baseArchiveVisitor) visitResource(file *zip.File) error      { return nil }
+
+// walkArchive streams each document, form, link map and resource in the archive to the visitor.
+// Files that can't be parsed are reported as item failures and skipped. Both XML and HTML exports
+// are supported.
+func walkArchive(ctx *Context, reader *zip.Reader, visitor archiveVisitor) error {
+	if isHtmlExport(reader) {
+		return walkHtmlArchive(ctx, reader, visitor)
+	}
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		var err error
+		fname := filename(f)
+		if isDocFile(fname) {
+			doc := &xmlDoc{}
+			if decodeErr := decodeZipFile(f, doc); decodeErr != nil {
+				reportUnparsableFile(ctx, f, decodeErr)
+				continue
+			}
+			err = visitor.visitDocument(doc)
+		} else if isFormFile(fname) {
+			form := &xmlForm{}
+			if decodeErr := decodeZipFile(f, form); decodeErr != nil {
+				reportUnparsableFile(ctx, f, decodeErr)
+				continue
+			}
+			err = visitor.visitForm(form)
+		} else if fname == "linkResolver.xml" {
+			links := &xmlLinkResolver{}
+			if decodeErr := decodeZipFile(f, links); decodeErr != nil {
+				reportUnparsableFile(ctx, f, decodeErr)
+				continue
+			}
+			err = visitor.visitLinks(links)
+		} else if isResource(f) {
+			err = visitor.visitResource(f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reportUnparsableFile(ctx *Context, f *zip.File, err error) {
+	ctx.reportItemFailure(filename(f), newCliError(EXIT_VALIDATION, "Could not parse, skipping: "+err.Error()))
+}
+
+// docCollector gathers all the documents in an archive
+type docCollector struct {
+	baseArchiveVisitor
+	docs []*xmlDoc
+}
+
+func (c *docCollector) visitDocument(doc *xmlDoc) error {
+	c.docs = append(c.docs, doc)
+	return nil
+}
+
+// parseArchiveFiles returns all the documents in the archive
+func parseArchiveFiles(ctx *Context, reader *zip.Reader) []*xmlDoc {
+	collector := &docCollector{docs: make([]*xmlDoc, 0)}
+	walkArchive(ctx, reader, collector)
+	return collector.docs
+}
+
+func isDocFile(fname string) bool {
+	return strings.HasSuffix(fname, "xml") && strings.HasPrefix(fname, "doc") && !isFormFile(fname)
+}
+
+func isFormFile(fname string) bool {
+	return strings.HasPrefix(fname, "doc") && strings.HasSuffix(fname, "_form.xml")
+}
+
+// isResource is true if the file is stored in a 'resources' folder of the archive
+func isResource(file *zip.File) bool {
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(file.Name)), "/") {
+		if dir == "resources" {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeZipFile streams XML content of a file in the archive into target, closing the file
+// once read.
+func decodeZipFile(file *zip.File, target interface{}) error {
+	fc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer fc.Close()
+	return xml.NewDecoder(fc).Decode(target)
+}
+
+// formForDoc reads the form definition stored next to a document file
+func formForDoc(reader *zip.Reader, docFile *zip.File) (*xmlForm, error) {
+	formPath := strings.TrimSuffix(docFile.Name, ".xml") + "_form.xml"
+	for _, f := range reader.File {
+		if f.Name == formPath {
+			form := &xmlForm{}
+			err := decodeZipFile(f, form)
+			return form, err
+		}
+	}
+	return nil, errors.New("No form definition found")
+}
+
+func filename(file *zip.File) string {
+	return filepath.Base(file.Name)
+}
+
+func showManifest(reader *zip.Reader) ([]byte, error) {
+	for _, f := range reader.File {
+		if filename(f) == "manifest.txt" {
+			fc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer fc.Close()
+			return ioutil.ReadAll(fc)
+		}
+	}
+	return nil, errors.New("No manifest.txt file found")
+}
@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestReadArchives(t *testing.T) {
 	archiveArgsA.summaryArg = true
-	summaries, _ := inspectArchives([]string{"testData/rs3.zip", "testData/rs2.zip"},
+	summaries, _ := inspectArchives(&Context{}, []string{"testData/rs3.zip", "testData/rs2.zip"},
 		&archiveArgsA)
 	if len(summaries) != 2 {
 		t.Fatalf("Expected %d results but got %d", 2, len(summaries))
@@ -15,7 +21,7 @@ func TestReadArchives(t *testing.T) {
 
 func TestReadArchive(t *testing.T) {
 	archiveArgsA.summaryArg = true
-	summaries, _ := inspectArchives([]string{"testData/rs3.zip"},
+	summaries, _ := inspectArchives(&Context{}, []string{"testData/rs3.zip"},
 		&archiveArgsA)
 	if len(summaries) != 1 {
 		t.Fatalf("Expected %d results but got %d", 1, len(summaries))
@@ -40,3 +46,189 @@ func TestReadArchive(t *testing.T) {
 		t.Fatalf("Authors should be user5e")
 	}
 }
+
+func TestReadArchiveStats(t *testing.T) {
+	archiveArgsA.summaryArg = true
+	summaries, _ := inspectArchives(&Context{}, []string{"testData/rs3.zip"},
+		&archiveArgsA)
+	summary := summaries[0]
+	if summary.FormCounts["Basic Document"] != 3 {
+		t.Fatalf("Expected 3 docs created from 'Basic Document' but got %v", summary.FormCounts)
+	}
+	if summary.AuthorCounts["user5e"] != 3 {
+		t.Fatalf("Expected 3 docs by user5e but got %v", summary.AuthorCounts)
+	}
+	if summary.AttachmentCount != 1 || summary.AttachmentSize != 1104 {
+		t.Fatalf("Expected 1 attachment of 1104 bytes but got %d of %d bytes",
+			summary.AttachmentCount, summary.AttachmentSize)
+	}
+	if summary.LinkCount != 0 {
+		t.Fatalf("Expected no links but got %d", summary.LinkCount)
+	}
+	if summary.MinModified.IsZero() || summary.MinModified.After(summary.MaxModified) {
+		t.Fatalf("Min modified date %s must be set and before %s", summary.MinModified, summary.MaxModified)
+	}
+}
+
+func TestReadArchivesInParallel(t *testing.T) {
+	config := archiveArgs{summaryArg: true, parallel: 2}
+	files := []string{"testData/rs3.zip", "testData/rs2.zip", "testData/rs3.zip"}
+	summaries, _ := inspectArchives(&Context{}, files, &config)
+	if len(summaries) != 3 {
+		t.Fatalf("Expected %d results but got %d", 3, len(summaries))
+	}
+	// results are in argument order
+	for i, expected := range []string{"rs3.zip", "rs2.zip", "rs3.zip"} {
+		if summaries[i].FileName != expected {
+			t.Fatalf("Expected result %d to be for %s but was %s", i, expected, summaries[i].FileName)
+		}
+	}
+}
+
+func TestParseArchiveFiles(t *testing.T) {
+	reader, err := zip.OpenReader("testData/rs2.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	docs := parseArchiveFiles(&Context{}, &reader.Reader)
+	if len(docs) != 2 {
+		t.Fatalf("Expected %d docs but got %d", 2, len(docs))
+	}
+}
+
+func TestReadHtmlArchive(t *testing.T) {
+	config := archiveArgs{summaryArg: true}
+	summaries, _ := inspectArchives(&Context{}, []string{"testData/rs-html.zip"}, &config)
+	if len(summaries) != 1 {
+		t.Fatalf("Expected %d results but got %d", 1, len(summaries))
+	}
+	summary := summaries[0]
+	if summary.DocCount != 2 {
+		t.Fatalf("Expected %d docs in archive but got %d", 2, summary.DocCount)
+	}
+	if len(summary.Authors) != 2 || summary.Authors[0] != "bobsmith" || summary.Authors[1] != "user5e" {
+		t.Fatalf("Expected authors bobsmith and user5e but got %v", summary.Authors)
+	}
+	if summary.FormCounts["PCR setup"] != 1 || summary.FormCounts["Basic Document"] != 1 {
+		t.Fatalf("Unexpected form counts %v", summary.FormCounts)
+	}
+	if summary.AttachmentCount != 1 {
+		t.Fatalf("Expected 1 attachment but got %d", summary.AttachmentCount)
+	}
+	if summary.MinDate.IsZero() || summary.MaxModified.IsZero() {
+		t.Fatalf("Dates should be parsed from HTML")
+	}
+}
+
+func TestXSummaryHtmlArchive(t *testing.T) {
+	docs, err := xSummary(&Context{}, []string{"testData/rs-html.zip"}, &archiveArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("Expected %d docs but got %d", 2, len(docs))
+	}
+	doc := docs[0]
+	if doc.Name != "Experiment 1" || doc.Tags != "pcr,mouse" || doc.CreatedBy != "user5e" {
+		t.Fatalf("Unexpected metadata %+v", doc)
+	}
+	// a table in the document's content doesn't change its metadata
+	doc = docs[1]
+	if doc.Name != "Results" || doc.Tags != "" || doc.CreatedBy != "bobsmith" {
+		t.Fatalf("Unexpected metadata %+v", doc)
+	}
+}
+
+func TestParseHtmlDocMetadataTable(t *testing.T) {
+	page := `<html><head><title>Page title</title></head><body>
+<table class="layout"><tr><td>Name</td><td>Not the name</td></tr></table>
+<table class="metadata"><tr><th>Name:</th><td>Plasmid prep</td></tr>
+<tr><th>Author:</th><td>someone</td></tr><tr><th>Owner:</th><td>Ann Lee (alee)</td></tr></table>
+<div class="field"><table><tr><td>Owner</td><td>Bob (bob)</td></tr></table></div></body></html>`
+	parsed, err := parseHtmlDoc(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualString(t, "Plasmid prep", parsed.doc.Name)
+	assertEqualString(t, "alee", parsed.doc.CreatedBy)
+
+	// without a metadata class, the first table before any field is used
+	page = `<html><body><table><tr><td>Tags:</td><td>a,b</td></tr></table>
+<div class="field"><table><tr><td>Tags</td><td>c</td></tr></table></div></body></html>`
+	parsed, _ = parseHtmlDoc(strings.NewReader(page))
+	assertEqualString(t, "a,b", parsed.doc.Tags)
+	if !parsed.hasMetadata {
+		t.Errorf("Expected metadata to be found")
+	}
+
+	// pages without metadata are named after their title, with unquoted attributes and unclosed tags
+	page = `<html><head><title> Gel &amp; blot </title><body class=page><p>Some <b>notes<table><tr><td>Owner<td>bob</table>`
+	parsed, err = parseHtmlDoc(strings.NewReader(page))
+	if err != nil || parsed.doc.Name != "Gel & blot" || parsed.hasMetadata {
+		t.Errorf("Expected page to be named from its title, without metadata, but got %+v, %v", parsed, err)
+	}
+}
+
+func TestHtmlArchivePagesWithoutMetadataAreWarned(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archives")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "export.zip")
+	out, _ := os.Create(file)
+	w := zip.NewWriter(out)
+	f, _ := w.Create("RSpace-html/Notes-12/Notes-12.html")
+	f.Write([]byte("<html><head><title>Notes</title></head><body><div class=\"field\">text</div></body></html>"))
+	w.Close()
+	out.Close()
+
+	ctx, _, _, errOut := newMemoryContext("table")
+	docs, err := xSummary(ctx, []string{file}, &archiveArgs{})
+	if err != nil || len(docs) != 1 || docs[0].Name != "Notes" {
+		t.Fatalf("Expected a document named from the page title but got %v, %v", docs, err)
+	}
+	assertEqualString(t, "Warning: Notes-12.html has no metadata table, so only its name is known, from its title\n", errOut.String())
+}
+
+func TestIsHtmlDocFile(t *testing.T) {
+	cases := map[string]bool{
+		"RSpace-html/Results-2802/Results-2802.html": true,
+		"RSpace-html/index.html":                     false,
+		"RSpace-html/Results-2802/attachment.html":   false,
+		"RSpace-html/resources/Results/Results.html": false,
+		"RSpace-html/Results-2802/Results-2802.htm":  false,
+	}
+	for name, expected := range cases {
+		if isHtmlDocFile(&zip.File{FileHeader: zip.FileHeader{Name: name}}) != expected {
+			t.Errorf("%s: expected isHtmlDocFile to be %t", name, expected)
+		}
+	}
+}
+
+func TestUnparsableArchiveFilesAreItemFailures(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archives")
+	defer os.RemoveAll(dir)
+	files := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("export%d.zip", i))
+		out, _ := os.Create(file)
+		w := zip.NewWriter(out)
+		f, _ := w.Create("doc_1/doc_1.xml")
+		f.Write([]byte("<document><name>ok</name><createdBy>user1</createdBy></document>"))
+		f, _ = w.Create("doc_2/doc_2.xml")
+		f.Write([]byte("<document><name>unclosed"))
+		w.Close()
+		out.Close()
+		files = append(files, file)
+	}
+	ctx, _, _, errOut := newMemoryContext("json")
+	summaries, _ := inspectArchives(ctx, files, &archiveArgs{summaryArg: true, parallel: 4})
+	if len(summaries) != 4 || summaries[0].DocCount != 1 {
+		t.Fatalf("Expected the parsable document of each archive to be summarised but got %v", summaries)
+	}
+	if ctx.ItemFailures != 4 || strings.Count(errOut.String(), `{"item":"doc_2.xml","error":{"code":2`) != 4 {
+		t.Errorf("Expected 4 unparsable files to be reported but got %d:\n%s", ctx.ItemFailures, errOut.String())
+	}
+	if exitCodeFor(ctx.itemFailuresError()) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected a partial failure")
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+// RSpaceClient is every RSpace API call made by the CLI. It is implemented by *rspace.RsWebClient,
+// and by memoryRSpace, an in-memory RSpace for testing commands offline.
+type RSpaceClient interface {
+	DocEditClient
+	StatusCli
+	Documents(cfg rspace.RecordListingConfig) (*rspace.DocumentList, error)
+	SearchDocuments(cfg rspace.RecordListingConfig, query string) (*rspace.DocumentList, error)
+	AdvancedSearchDocuments(cfg rspace.RecordListingConfig, query *rspace.SearchQuery) (*rspace.DocumentList, error)
+	ImportWord(path string, folderId, imageFolderId int) (*rspace.DocumentInfo, error)
+
+	FolderNew(post *rspace.FolderPost) (*rspace.Folder, error)
+	FolderById(id int) (*rspace.Folder, error)
+	FolderTree(cfg rspace.RecordListingConfig, folderId int, typesToInclude []string) (*rspace.FolderList, error)
+
+	Files(cfg rspace.RecordListingConfig, mediaType string) (*rspace.FileList, error)
+	UploadFile(config rspace.FileUploadConfig) (*rspace.FileInfo, error)
+	Download(id int, dir string) (*rspace.FileInfo, error)
+
+	Forms(cfg rspace.RecordListingConfig) (*rspace.FormList, error)
+	FormById(id int) (*rspace.Form, error)
+	CreateFormJson(jsonFormDef io.Reader) (*rspace.Form, error)
+	CreateFormYaml(yamlFormDef io.Reader) (*rspace.Form, error)
+	PublishForm(formId int) (*rspace.Form, error)
+
+	Activities(query *rspace.ActivityQuery, cfg rspace.RecordListingConfig) (*rspace.ActivityList, error)
+	Share(post *rspace.SharePost) (*rspace.ShareInfoList, error)
+
+	Export(post rspace.ExportPost, waitForComplete bool, reporter func(string)) (*rspace.Job, error)
+	GetJob(jobId int) (*rspace.Job, error)
+	DownloadExport(url *url.URL, outWriter io.Writer) error
+
+	Groups() (*rspace.GroupList, error)
+	GroupNew(post *rspace.GroupPost) (*rspace.GroupInfo, error)
+	Users(lastLoginBefore, creationDateBefore time.Time, cfg rspace.RecordListingConfig) (*rspace.UserList, error)
+	UserNew(post *rspace.UserPost) (*rspace.UserInfo, error)
+}
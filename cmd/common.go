@@ -9,7 +9,6 @@ import (
 	"os"
 	"regexp"
 	"strconv"
-	"strings"
 
 	"github.com/richarda23/rspace-client-go/rspace"
 	//"errors"
@@ -19,12 +18,17 @@ const (
 	DISPLAY_TIMESTAMP_WIDTH = 16
 )
 
+// exitWithStdErrMsg exits after invalid arguments or input
 func exitWithStdErrMsg(message string) {
-	messageStdErr(message)
-	os.Exit(1)
+	exitWithErr(newCliError(EXIT_VALIDATION, message))
 }
+
+// exitWithErr reports an error, in the format given by the --outputFormat flag, and exits with
+// the exit code for that kind of error
 func exitWithErr(err error) {
-	exitWithStdErrMsg(err.Error())
+	code := exitCodeFor(err)
+	writeError(os.Stderr, outputFmt(outputFormatArg), "", code, err.Error())
+	os.Exit(code)
 }
 func messageStdErr(message string) {
 	fmt.Fprintln(os.Stderr, message)
@@ -49,15 +53,61 @@ func configurePagination() rspace.RecordListingConfig {
 	if pageSizeArg > 0 {
 		cfg.PageSize = pageSizeArg
 	}
+	if pageNumberArg > 0 {
+		cfg.PageNumber = pageNumberArg
+	}
 	return cfg
 }
 
-func idsFromGlobalIds(globalIds []string) []int {
+// fetches a single page of results, returning the total number of hits
+// and the number of results in the page
+type pageFetcher func(cfg rspace.RecordListingConfig) (totalHits int, pageCount int, err error)
+
+// pageIterator walks through the pages of a listing. If 'all' is set every page
+// from the starting page onwards is fetched, otherwise just the starting page.
+type pageIterator struct {
+	ctx *Context
+	cfg rspace.RecordListingConfig
+	all bool
+	// report how many results have been retrieved after each page
+	progress  bool
+	TotalHits int
+	Fetched   int
+}
+
+// newPageIterator reports progress when fetching all pages, if stderr is a terminal
+func newPageIterator(ctx *Context, cfg rspace.RecordListingConfig) *pageIterator {
+	return &pageIterator{ctx: ctx, cfg: cfg, all: allPagesArg, progress: allPagesArg && isTerminalWriter(ctx.errWriter())}
+}
+
+// forEachPage calls fetch for each page to retrieve
+func (it *pageIterator) forEachPage(fetch pageFetcher) error {
+	// results in the pages before the starting page
+	skipped := it.cfg.PageNumber * it.cfg.PageSize
+	for {
+		totalHits, pageCount, err := fetch(it.cfg)
+		if err != nil {
+			return err
+		}
+		it.TotalHits = totalHits
+		it.Fetched += pageCount
+		if it.progress {
+			it.ctx.messageStdErr(fmt.Sprintf("Retrieved %d of %d results", it.Fetched, it.TotalHits-skipped))
+		}
+		if !it.all || pageCount == 0 || it.Fetched >= it.TotalHits-skipped {
+			break
+		}
+		it.cfg.PageNumber++
+	}
+	return nil
+}
+
+func (ctx *Context) idsFromGlobalIds(globalIds []string) []int {
 	ids := make([]int, 0)
 	for _, v := range globalIds {
 		id, err := idFromGlobalId(v)
 		if err != nil || id == 0 {
-			messageStdErr(fmt.Sprintf("%s is not a valid identifier, skipping", v))
+			ctx.reportItemFailure(v, newCliError(EXIT_VALIDATION, "not a valid identifier, skipping"))
 		} else {
 			ids = append(ids, id)
 		}
@@ -104,15 +154,15 @@ type ResultListFormatter interface {
 }
 
 func printTable(ctx *Context, table *TableResult) {
-	printTableHeaders(ctx, table.Headers)
-	printContent(ctx, table)
+	renderer := newTableRenderer(ctx, table.Headers)
+	renderer.writeHeaders(table.Headers)
+	renderer.writeRows(table.Content)
+	renderer.close()
 }
-func printCsv(ctx *Context, table *TableResult) {
+func printCsv(ctx *Context, table *TableResult) error {
 	writer := csv.NewWriter(ctx.Writer)
 	writer.Write(columnDefsToString(table.Headers))
-	if err := writer.WriteAll(table.Content); err != nil {
-		exitWithErr(err)
-	}
+	return writer.WriteAll(table.Content)
 }
 
 func columnDefsToString(headers []columnDef) []string {
@@ -123,29 +173,10 @@ func columnDefsToString(headers []columnDef) []string {
 	return rowToPrint
 }
 
-func printContent(ctx *Context, table *TableResult) {
-	for _, row := range table.Content {
-		rowToPrint := make([]string, 0)
-		for i, cell := range row {
-			toPrint := abbreviate(cell, table.Headers[i].Width)
-			toPrint = fmt.Sprintf("%-[1]*s", table.Headers[i].Width, toPrint)
-			rowToPrint = append(rowToPrint, toPrint)
-		}
-		ctx.write(strings.Join(rowToPrint, "\t"))
-	}
-}
-func printTableHeaders(ctx *Context, headers []columnDef) {
-	headersToPrint := make([]string, 0)
-	for _, header := range headers {
-		toPrint := abbreviate(header.Title, header.Width)
-		toPrint = fmt.Sprintf("%-[1]*s", header.Width, toPrint)
-		headersToPrint = append(headersToPrint, toPrint)
-	}
-	ctx.write(strings.Join(headersToPrint, "\t"))
-}
+// abbreviate shortens a string to maxLen terminal columns, never splitting a character
 func abbreviate(toAbbreviate string, maxLen int) string {
-	if maxLen > 3 && len(toAbbreviate) > maxLen {
-		toAbbreviate = toAbbreviate[0:(maxLen-2)] + ".."
+	if maxLen > 3 && displayWidth(toAbbreviate) > maxLen {
+		toAbbreviate = truncateToWidth(toAbbreviate, maxLen-2) + ".."
 	}
 	return toAbbreviate
 }
@@ -166,7 +197,7 @@ func getMaxNameLength(results []rspace.BasicInfo) int {
 	var maxPossible float64 = 25
 	var currLongest float64 = 0
 	for _, res := range results {
-		if nameLen := float64(len(res.GetName())); nameLen > currLongest {
+		if nameLen := float64(displayWidth(res.GetName())); nameLen > currLongest {
 			currLongest = math.Min(maxPossible, nameLen)
 		}
 	}
@@ -53,3 +53,55 @@ func assertIdEquals(t *testing.T, got, expected int) {
 		t.Fatalf("Expected %d but was %d", expected, got)
 	}
 }
+
+func TestPageIterator(t *testing.T) {
+	pagesFetched := make([]int, 0)
+	fetch := func(cfg rspace.RecordListingConfig) (int, int, error) {
+		pagesFetched = append(pagesFetched, cfg.PageNumber)
+		// 45 results in pages of 20
+		remaining := 45 - cfg.PageNumber*cfg.PageSize
+		if remaining > cfg.PageSize {
+			remaining = cfg.PageSize
+		}
+		return 45, remaining, nil
+	}
+	cfg := rspace.RecordListingConfig{PageSize: 20}
+	it := &pageIterator{cfg: cfg, all: true}
+	if err := it.forEachPage(fetch); err != nil {
+		t.Fatal(err)
+	}
+	if len(pagesFetched) != 3 || pagesFetched[2] != 2 || it.Fetched != 45 || it.TotalHits != 45 {
+		t.Fatalf("Expected 3 pages and 45 results but got pages %v, %d results", pagesFetched, it.Fetched)
+	}
+
+	pagesFetched = make([]int, 0)
+	cfg.PageNumber = 1
+	it = &pageIterator{cfg: cfg}
+	it.forEachPage(fetch)
+	if len(pagesFetched) != 1 || pagesFetched[0] != 1 || it.Fetched != 20 {
+		t.Fatalf("Expected only page 1 to be fetched but got %v", pagesFetched)
+	}
+
+	// starting from page 1, the 25 remaining results are in 2 pages
+	pagesFetched = make([]int, 0)
+	it = &pageIterator{cfg: cfg, all: true}
+	it.forEachPage(fetch)
+	if len(pagesFetched) != 2 || pagesFetched[1] != 2 || it.Fetched != 25 {
+		t.Fatalf("Expected pages 1 and 2 to be fetched but got %v", pagesFetched)
+	}
+}
+
+func TestPageIteratorProgress(t *testing.T) {
+	fetch := func(cfg rspace.RecordListingConfig) (int, int, error) {
+		return 30, 20 - cfg.PageNumber*10, nil
+	}
+	ctx, _, _, errOut := newMemoryContext("table")
+	if err := newPageIterator(ctx, rspace.RecordListingConfig{PageSize: 20}).forEachPage(fetch); err != nil {
+		t.Fatal(err)
+	}
+	assertEqualString(t, "", errOut.String())
+
+	it := &pageIterator{ctx: ctx, cfg: rspace.RecordListingConfig{PageSize: 20}, all: true, progress: true}
+	it.forEachPage(fetch)
+	assertEqualString(t, "Retrieved 20 of 30 results\nRetrieved 30 of 30 results\n", errOut.String())
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// executeHtmlTemplate renders an HTML template, escaping the data it's given
+func executeHtmlTemplate(name, text string, data interface{}, options ...string) (string, error) {
+	t, err := template.New(name).Option(options...).Parse(text)
+	if err != nil {
+		return "", newCliError(EXIT_VALIDATION, fmt.Sprintf("Invalid template: %s", err.Error()))
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return "", newCliError(EXIT_VALIDATION, fmt.Sprintf("Couldn't apply template: %s", err.Error()))
+	}
+	return buf.String(), nil
+}
+
+// templateVars combines the variables in a vars file with those set by --var 'name=value' arguments,
+// which take precedence. Vars files are JSON or YAML objects, or 'name=value' lines.
+func templateVars(varsFile string, varArgs []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	if len(varsFile) > 0 {
+		var err error
+		if vars, err = readVarsFile(varsFile); err != nil {
+			return nil, err
+		}
+	}
+	for _, varArg := range varArgs {
+		parts := strings.SplitN(varArg, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("--var should be 'name=value', but was '%s'", varArg))
+		}
+		vars[strings.TrimSpace(parts[0])] = parts[1]
+	}
+	return vars, nil
+}
+
+func readVarsFile(path string) (map[string]string, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format == "yml" {
+		format = "yaml"
+	}
+	if format != "json" && format != "yaml" {
+		return readKeyValueFile(path, "name=value")
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root *jsonNode
+	if format == "yaml" {
+		root, err = parseYaml(string(content))
+	} else {
+		root, err = parseJsonInput(content)
+	}
+	if err == nil && !root.isMap {
+		err = errors.New("expected an object of variable names and values")
+	}
+	if err != nil {
+		return nil, inputError(path, err)
+	}
+	vars := make(map[string]string)
+	for i, key := range root.keys {
+		value, err := recordValue(root.values[i])
+		if err != nil {
+			return nil, inputError(path, errors.New(fmt.Sprintf("'%s': %s", key, err.Error())))
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// renderContentTemplate renders a template file of document content with variables.
+// All the variables the template uses must be set.
+func renderContentTemplate(templateFile string, vars map[string]string) (string, error) {
+	text, err := ioutil.ReadFile(templateFile)
+	if err != nil {
+		return "", err
+	}
+	content, err := executeHtmlTemplate(filepath.Base(templateFile), string(text), vars, "missingkey=error")
+	if err != nil {
+		names := make([]string, 0)
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", newCliError(EXIT_VALIDATION, fmt.Sprintf("%s. Variables set are '%s'", err.Error(), strings.Join(names, "', '")))
+	}
+	return content, nil
+}
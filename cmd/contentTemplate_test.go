@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const protocolTemplate = `<h2>PCR of {{.sample}}</h2><p>Run on {{.date}} by {{.operator}}</p>`
+
+func writeTemplateFiles(t *testing.T, files map[string]string) (string, func()) {
+	dir, _ := ioutil.TempDir("", "contentTemplate")
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Couldn't write %s: %v", name, err)
+		}
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestAddDocumentFromTemplate(t *testing.T) {
+	dir, cleanup := writeTemplateFiles(t, map[string]string{
+		"pcr.html":  protocolTemplate,
+		"vars.yaml": "operator: Alice\nsample: XYZ\n",
+		"vars.txt":  "# defaults\noperator=Bob\n",
+	})
+	defer cleanup()
+	ctx, client, _, _ := newMemoryContext("quiet")
+	args := addDocArgs{NameArg: "pcr", ContentTemplate: filepath.Join(dir, "pcr.html"), VarsFile: filepath.Join(dir, "vars.yaml"),
+		Vars: []string{"sample=<ABC>", "date=2021-03-04"}}
+	if err := doAddDocRun(args, ctx, client); err != nil {
+		t.Fatalf("Expected document from template but got %v", err)
+	}
+	// --var takes precedence over the vars file, and values are escaped
+	assertEqualString(t, "<h2>PCR of &lt;ABC&gt;</h2><p>Run on 2021-03-04 by Alice</p>", client.documents[0].Fields[0].Content)
+
+	vars, err := templateVars(filepath.Join(dir, "vars.txt"), []string{"date=today"})
+	if err != nil || vars["operator"] != "Bob" || vars["date"] != "today" {
+		t.Errorf("Expected vars from name=value lines but got %v, %v", vars, err)
+	}
+}
+
+func TestContentTemplateWithOutputTemplate(t *testing.T) {
+	dir, cleanup := writeTemplateFiles(t, map[string]string{"pcr.html": protocolTemplate})
+	defer cleanup()
+	ctx, client, out, _ := newMemoryContext("template")
+	// --template formats the output, --content-template the document
+	ctx.Template = "{{.Name}}"
+	args := addDocArgs{NameArg: "pcr", ContentTemplate: filepath.Join(dir, "pcr.html"),
+		Vars: []string{"sample=ABC", "date=today", "operator=Alice"}}
+	if err := doAddDocRun(args, ctx, client); err != nil {
+		t.Fatalf("Expected document from template but got %v", err)
+	}
+	assertEqualString(t, "pcr\n", out.String())
+}
+
+func TestTemplateErrors(t *testing.T) {
+	dir, cleanup := writeTemplateFiles(t, map[string]string{"pcr.html": protocolTemplate, "bad.html": "{{.sample"})
+	defer cleanup()
+	ctx, client, _, _ := newMemoryContext("quiet")
+	form := addSampleForm(client)
+	pcr := filepath.Join(dir, "pcr.html")
+	invalid := []addDocArgs{
+		addDocArgs{ContentTemplate: pcr, Vars: []string{"sample=ABC", "date=today"}},
+		addDocArgs{ContentTemplate: filepath.Join(dir, "bad.html")},
+		addDocArgs{ContentTemplate: pcr, Vars: []string{"sample"}},
+		addDocArgs{ContentTemplate: pcr, Content: "x"},
+		addDocArgs{ContentTemplate: pcr, FormId: form.GlobalId},
+	}
+	for _, args := range invalid {
+		if err := doAddDocRun(args, ctx, client); exitCodeFor(err) != EXIT_VALIDATION {
+			t.Errorf("Expected a validation error for %+v but got %v", args, err)
+		}
+	}
+	err := doAddDocRun(invalid[0], ctx, client)
+	if err == nil || !strings.Contains(err.Error(), "operator") || !strings.Contains(err.Error(), "'date', 'sample'") {
+		t.Errorf("Expected the missing variable and the variables set to be reported but got %v", err)
+	}
+	if len(client.documents) != 0 {
+		t.Errorf("Expected no documents to be created")
+	}
+}
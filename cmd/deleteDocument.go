@@ -0,0 +1,88 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+	"github.com/spf13/cobra"
+)
+
+type deleteDocArgs struct {
+	// delete without asking for confirmation
+	Yes bool
+}
+
+var deleteDocArgV = deleteDocArgs{}
+
+var deleteDocumentCmd = &cobra.Command{
+	Use:   "deleteDocument",
+	Short: "Deletes one or more documents",
+	Long: `Delete one or more documents, after asking for confirmation of each one.
+
+Use --yes to delete without confirmation, e.g. in scripts. The deleted documents are listed.
+Documents that aren't confirmed are skipped.
+	`,
+	Aliases: []string{"removeDocument", "docDelete"},
+	Example: `
+// delete a document, after confirming
+rspace eln deleteDocument SD123
+
+// delete several documents without confirmation
+rspace eln deleteDocument SD123 SD124 SD125 --yes
+
+// delete all documents with a tag
+rspace eln listDocuments --tag draft -f quiet | xargs rspace eln deleteDocument --yes
+	`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doDeleteDocuments(ctx, ctx.WebClient, args, deleteDocArgV)
+	},
+}
+
+func doDeleteDocuments(ctx *Context, docClient DocEditClient, idArgs []string, args deleteDocArgs) error {
+	deleted := make([]rspace.DocumentInfo, 0)
+	for _, id := range ctx.idsFromGlobalIds(idArgs) {
+		doc, err := docClient.DocumentById(id)
+		if err != nil {
+			ctx.reportItemFailure(fmt.Sprintf("SD%d", id), err)
+			continue
+		}
+		if !args.Yes && !ctx.confirm(fmt.Sprintf("Delete %s '%s'?", doc.GlobalId, doc.Name)) {
+			ctx.messageStdErr(fmt.Sprintf("Skipped %s", doc.GlobalId))
+			continue
+		}
+		if _, err := docClient.DeleteDocument(id); err != nil {
+			ctx.reportItemFailure(doc.GlobalId, err)
+			continue
+		}
+		deleted = append(deleted, *doc.DocumentInfo)
+	}
+	if err := ctx.writeResult(&DocListFormatter{&rspace.DocumentList{Documents: deleted}}); err != nil {
+		return err
+	}
+	return ctx.itemFailuresError()
+}
+
+func init() {
+	elnCmd.AddCommand(deleteDocumentCmd)
+	deleteDocumentCmd.Flags().BoolVarP(&deleteDocArgV.Yes, "yes", "y", false, "Delete without asking for confirmation")
+}
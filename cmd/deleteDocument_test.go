@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDeleteDocumentsAfterConfirmation(t *testing.T) {
+	ctx, client, out, errOut := newMemoryContext("quiet")
+	doc1, _ := client.NewBasicDocumentWithContent("doc1", "", "")
+	doc2, _ := client.NewBasicDocumentWithContent("doc2", "", "")
+	ctx.Reader = strings.NewReader("y\nn\n")
+
+	err := doDeleteDocuments(ctx, client, []string{doc1.GlobalId, doc2.GlobalId}, deleteDocArgs{})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if strings.TrimSpace(out.String()) != strconv.Itoa(doc1.Id) {
+		t.Errorf("Expected only %s to be deleted but got %s", doc1.GlobalId, out.String())
+	}
+	if !strings.Contains(errOut.String(), "Delete "+doc2.GlobalId+" 'doc2'? [y/N]") || !strings.Contains(errOut.String(), "Skipped "+doc2.GlobalId) {
+		t.Errorf("Expected confirmation prompts but got %s", errOut.String())
+	}
+	if len(client.documents) != 1 || client.documents[0].Id != doc2.Id {
+		t.Errorf("Expected %s to remain", doc2.GlobalId)
+	}
+}
+
+func TestDeleteDocumentsWithoutConfirmation(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	doc1, _ := client.NewBasicDocumentWithContent("doc1", "", "")
+
+	err := doDeleteDocuments(ctx, client, []string{doc1.GlobalId, "SD99999"}, deleteDocArgs{Yes: true})
+	if exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected partial failure for missing document but got %v", err)
+	}
+	if len(client.documents) != 0 {
+		t.Errorf("Expected %s to be deleted", doc1.GlobalId)
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+// what happened to each row of input data when creating documents
+const (
+	BATCH_VALID   = "valid"
+	BATCH_INVALID = "invalid"
+	BATCH_CREATED = "created"
+	BATCH_FAILED  = "failed"
+	BATCH_SKIPPED = "skipped"
+	BATCH_DELETED = "deleted"
+)
+
+// batchRow is the result of creating a document from a row of input data
+type batchRow struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Error  string `json:"error"`
+	post   *rspace.DocumentPost
+	doc    *rspace.DocumentInfo
+}
+
+// documentBatch creates documents from rows of input data. Every row is validated before any document is created.
+type documentBatch struct {
+	ctx       *Context
+	docClient AddDocClient
+	rows      []*batchRow
+	// stop at the first failure, rather than carrying on with other rows
+	failFast bool
+	// delete documents already created if a row fails
+	rollback bool
+	dryRun   bool
+}
+
+func (b *documentBatch) addRow(rowNumber int, post *rspace.DocumentPost, err error) {
+	row := &batchRow{Row: rowNumber, Status: BATCH_VALID, post: post}
+	if err != nil {
+		row.Status, row.Error = BATCH_INVALID, err.Error()
+		b.ctx.reportItemFailure(fmt.Sprintf("row %d", rowNumber), err)
+	} else {
+		row.Name = post.Name
+	}
+	b.rows = append(b.rows, row)
+}
+
+func (b *documentBatch) count(status string) int {
+	count := 0
+	for _, row := range b.rows {
+		if row.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// run creates a document for each valid row, returning the documents that were created
+func (b *documentBatch) run() []*rspace.DocumentInfo {
+	stopOnFailure := b.failFast || b.rollback
+	if b.dryRun {
+		b.ctx.messageStdErr(fmt.Sprintf("Dry run: %d of %d row(s) are valid. No documents were created",
+			b.count(BATCH_VALID), len(b.rows)))
+		return []*rspace.DocumentInfo{}
+	}
+	if stopOnFailure && b.count(BATCH_INVALID) > 0 {
+		b.skipRemaining()
+		b.ctx.messageStdErr("No documents were created, as some rows are invalid")
+		return []*rspace.DocumentInfo{}
+	}
+	for i, row := range b.rows {
+		if row.Status != BATCH_VALID {
+			continue
+		}
+		b.ctx.messageStdErr(fmt.Sprintf("%d of %d", i+1, len(b.rows)))
+		doc, err := b.docClient.NewDocumentWithContent(row.post)
+		if err != nil {
+			row.Status, row.Error = BATCH_FAILED, err.Error()
+			b.ctx.reportItemFailure(fmt.Sprintf("row %d", row.Row), err)
+			if stopOnFailure {
+				break
+			}
+			continue
+		}
+		row.Status, row.Id, row.Name, row.doc = BATCH_CREATED, doc.GlobalId, doc.Name, doc.DocumentInfo
+	}
+	if stopOnFailure && b.count(BATCH_FAILED) > 0 {
+		b.skipRemaining()
+		if b.rollback {
+			b.deleteCreated()
+		}
+	}
+	created := make([]*rspace.DocumentInfo, 0)
+	for _, row := range b.rows {
+		if row.Status == BATCH_CREATED {
+			created = append(created, row.doc)
+		}
+	}
+	return created
+}
+
+func (b *documentBatch) skipRemaining() {
+	for _, row := range b.rows {
+		if row.Status == BATCH_VALID {
+			row.Status = BATCH_SKIPPED
+		}
+	}
+}
+
+// deleteCreated deletes the documents created so far. Documents that can't be deleted are reported.
+func (b *documentBatch) deleteCreated() {
+	for _, row := range b.rows {
+		if row.Status != BATCH_CREATED {
+			continue
+		}
+		if _, err := b.docClient.DeleteDocument(row.doc.Id); err != nil {
+			row.Error = fmt.Sprintf("couldn't delete after a failure: %s", err.Error())
+			b.ctx.reportItemFailure(row.Id, err)
+			continue
+		}
+		b.ctx.messageStdErr(fmt.Sprintf("Deleted %s", row.Id))
+		row.Status = BATCH_DELETED
+	}
+}
+
+// writeResults writes the result of each row to a file, as JSON if the file name ends in '.json', otherwise CSV
+func (b *documentBatch) writeResults(path string) error {
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		bytes, err := json.MarshalIndent(b.rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, bytes, 0644)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write([]string{"Row", "Status", "Id", "Name", "Error"})
+	for _, row := range b.rows {
+		w.Write([]string{strconv.Itoa(row.Row), row.Status, row.Id, row.Name, row.Error})
+	}
+	w.Flush()
+	return w.Error()
+}
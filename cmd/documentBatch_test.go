@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+// failingCreator fails to create the document with a given name, as if the server rejected it
+type failingCreator struct {
+	*memoryRSpace
+	failName string
+}
+
+func (f *failingCreator) NewDocumentWithContent(post *rspace.DocumentPost) (*rspace.Document, error) {
+	if post.Name == f.failName {
+		return nil, errors.New("500 Internal server error")
+	}
+	return f.memoryRSpace.NewDocumentWithContent(post)
+}
+
+const batchTestInput = "Sample,Amount,Date,Stains,State\ns1,1,,,\ns2,2,,,\ns3,3,,,\n"
+
+// runBatch creates documents from rows s1, s2 and s3, failing to create s2, and returns the results file
+func runBatch(t *testing.T, args addDocArgs, input string) (*memoryRSpace, string, error) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	form := addSampleForm(client)
+	path, cleanup := writeTestCsv(t, input)
+	defer cleanup()
+	if len(args.ResultsFile) == 0 {
+		args.ResultsFile = "results.csv"
+	}
+	args.ResultsFile = filepath.Join(filepath.Dir(path), args.ResultsFile)
+	args.NameTemplate, args.FormId, args.InputData = "{{.Sample}}", form.GlobalId, path
+	// everything, including rollback, must go through the injected client
+	ctx.WebClient = nil
+	err := doAddDocRun(args, ctx, &failingCreator{client, "s2"})
+	results, _ := ioutil.ReadFile(args.ResultsFile)
+	return client, string(results), err
+}
+
+func TestBatchCarriesOnAfterFailures(t *testing.T) {
+	client, results, err := runBatch(t, addDocArgs{}, batchTestInput)
+	if exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected partial failure but got %v", err)
+	}
+	assertEqualString(t, "s1,s3", createdNames(client))
+	expected := "Row,Status,Id,Name,Error\n" +
+		"1,created," + client.documents[0].GlobalId + ",s1,\n" +
+		"2,failed,,s2,500 Internal server error\n" +
+		"3,created," + client.documents[1].GlobalId + ",s3,\n"
+	assertEqualString(t, expected, results)
+}
+
+func TestBatchFailFast(t *testing.T) {
+	client, results, err := runBatch(t, addDocArgs{FailFast: true}, batchTestInput)
+	if exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected partial failure but got %v", err)
+	}
+	assertEqualString(t, "s1", createdNames(client))
+	if !strings.HasSuffix(results, "2,failed,,s2,500 Internal server error\n3,skipped,,s3,\n") {
+		t.Errorf("Expected row 3 to be skipped but results were\n%s", results)
+	}
+
+	// invalid rows are found before anything is created
+	client, results, _ = runBatch(t, addDocArgs{FailFast: true}, batchTestInput+"s4,many,,,\n")
+	if len(client.documents) != 0 {
+		t.Errorf("Expected no documents but got %s", createdNames(client))
+	}
+	if !strings.Contains(results, "1,skipped,,s1,\n") || !strings.Contains(results, "4,invalid,,,column 'Amount'") {
+		t.Errorf("Expected valid rows to be skipped but results were\n%s", results)
+	}
+}
+
+func TestBatchRollback(t *testing.T) {
+	client, results, err := runBatch(t, addDocArgs{Rollback: true, ResultsFile: "results.json"}, batchTestInput)
+	if exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected partial failure but got %v", err)
+	}
+	if len(client.documents) != 0 {
+		t.Errorf("Expected created documents to be deleted, but %s remain", createdNames(client))
+	}
+	rows := make([]batchRow, 0)
+	if err := json.Unmarshal([]byte(results), &rows); err != nil {
+		t.Fatalf("Expected JSON results but got %v: %s", err, results)
+	}
+	statuses := make([]string, 0)
+	for _, row := range rows {
+		statuses = append(statuses, row.Status)
+	}
+	assertEqualString(t, "deleted,failed,skipped", strings.Join(statuses, ","))
+	if len(rows[0].Id) == 0 {
+		t.Errorf("Expected the id of the deleted document to be recorded")
+	}
+}
+
+func TestBatchDryRun(t *testing.T) {
+	client, results, err := runBatch(t, addDocArgs{DryRun: true}, batchTestInput+"s4,,not a date,,\n")
+	if exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected partial failure for an invalid row but got %v", err)
+	}
+	if len(client.documents) != 0 {
+		t.Errorf("Expected no documents from a dry run but got %s", createdNames(client))
+	}
+	if !strings.Contains(results, "1,valid,,s1,\n2,valid,,s2,\n3,valid,,s3,\n4,invalid,,,column 'Date'") {
+		t.Errorf("Expected each row to be validated but results were\n%s", results)
+	}
+
+	_, _, err = runBatch(t, addDocArgs{DryRun: true}, batchTestInput)
+	if err != nil {
+		t.Errorf("Expected valid input to pass a dry run but got %v", err)
+	}
+}
+
+func TestBatchOptionsRequireInput(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	for _, args := range []addDocArgs{
+		addDocArgs{NameArg: "single", Content: "x", DryRun: true},
+		addDocArgs{NameArg: "single", Content: "x", FailFast: true},
+		addDocArgs{NameArg: "single", Content: "x", Rollback: true},
+		addDocArgs{NameArg: "single", Content: "x", ResultsFile: "results.csv"},
+	} {
+		if err := doAddDocRun(args, ctx, client); exitCodeFor(err) != EXIT_VALIDATION {
+			t.Errorf("Expected a validation error for %+v but got %v", args, err)
+		}
+	}
+	if len(client.documents) != 0 {
+		t.Errorf("Expected no documents to be created but got %s", createdNames(client))
+	}
+}
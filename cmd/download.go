@@ -16,8 +16,8 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/richarda23/rspace-client-go/rspace"
 	"github.com/spf13/cobra"
@@ -48,52 +48,58 @@ rspace eln download GL1234 GL12345--dir /downloadFolder
 	`,
 	Args: cobra.MinimumNArgs(1),
 
-	Run: func(cmd *cobra.Command, args []string) {
-		//	post := rspace.FolderPost{IsNotebook: false}
-		ctx := initialiseContext()
-		ids := validateDownloadArgs(args)
-		info := doDownload(ctx, ids)
-		ctx.writeResult(info)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		ids, err := validateDownloadArgs(ctx, args)
+		if err != nil {
+			return err
+		}
+		return doDownload(ctx, ids)
 	},
 }
 
-// TODO hande multiple FileIds
-func doDownload(ctx *Context, ids []int) *FileListFormatter {
+func doDownload(ctx *Context, ids []int) error {
 	var results = make([]*rspace.FileInfo, 0)
 	for _, id := range ids {
 		info, err := ctx.WebClient.Download(id, dArgs.OutfolderArg)
 		if err != nil {
-			messageStdErr(err.Error())
+			ctx.reportItemFailure(strconv.Itoa(id), err)
 		} else {
 			results = append(results, info)
 		}
 	}
 	var fal = FileArrayList{results}
-	return &FileListFormatter{fal}
+	if err := ctx.writeResult(&FileListFormatter{fal}); err != nil {
+		return err
+	}
+	return ctx.itemFailuresError()
 }
 
-func validateDownloadArgs(args []string) []int {
+func validateDownloadArgs(ctx *Context, args []string) ([]int, error) {
 	ids := make([]int, 0)
 	for _, idStr := range args {
 		id, err := idFromGlobalId(idStr)
-		if err != nil {
-			messageStdErr(idStr + " is not valid id, skipping")
+		if err != nil || id == 0 {
+			ctx.reportItemFailure(idStr, newCliError(EXIT_VALIDATION, "not a valid id, skipping"))
+			continue
 		}
 		ids = append(ids, id)
 	}
 	if len(dArgs.OutfolderArg) > 0 {
 		stats, err := os.Stat(dArgs.OutfolderArg)
 		if err != nil {
-			exitWithErr(err)
+			return nil, err
 		}
 		if !stats.IsDir() {
-			exitWithStdErrMsg("Not a directory")
+			return nil, newCliError(EXIT_VALIDATION, "Not a directory")
 		}
 	} else {
 		dArgs.OutfolderArg = "./"
 	}
-	fmt.Println(ids)
-	return ids
+	return ids, nil
 }
 
 func init() {
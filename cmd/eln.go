@@ -20,10 +20,18 @@ import (
 )
 
 var outputFormatArg string
+var columnsArg string
+var templateArg string
+var queryArg string
+var wrapArg bool
+var bordersArg bool
+var colourArg string
 var outFileArg string
 var sortOrderArg string
 var orderByArg string
 var pageSizeArg int
+var pageNumberArg int
+var allPagesArg bool
 
 // elnCmd represents the eln command
 var elnCmd = &cobra.Command{
@@ -39,6 +47,12 @@ var elnCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(elnCmd)
-	elnCmd.PersistentFlags().StringVarP(&outputFormatArg, "outputFormat", "f", "table", "Output format: one of 'json','table', 'csv' or 'quiet' ")
+	elnCmd.PersistentFlags().StringVarP(&outputFormatArg, "outputFormat", "f", "table", outputFormatHelp)
+	elnCmd.PersistentFlags().StringVar(&columnsArg, "columns", "", "Comma-separated list of fields to show in table, csv or tsv output, e.g. 'name,globalId,tags'")
+	elnCmd.PersistentFlags().StringVar(&templateArg, "template", "", "Go template applied to each result when using '-f template', e.g. '{{.GlobalId}} {{.Name}}'")
+	elnCmd.PersistentFlags().BoolVar(&wrapArg, "wrap", false, "Wrap long values in table output onto several lines, rather than abbreviating them")
+	elnCmd.PersistentFlags().BoolVar(&bordersArg, "borders", false, "Draw borders around table output")
+	elnCmd.PersistentFlags().StringVar(&colourArg, "colour", "never", "Highlight table headers: one of 'never', 'always' or 'auto' (only when writing to a terminal)")
+	elnCmd.PersistentFlags().StringVar(&queryArg, "jmespath", "", "JMESPath expression applied to the JSON form of the results, e.g. \"Documents[?Tags == 'pcr'].GlobalId\"")
 	elnCmd.PersistentFlags().StringVarP(&outFileArg, "outFile", "o", "", "Output file for program output")
 }
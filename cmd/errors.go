@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+)
+
+// Exit codes, so that scripts can tell why a command failed
+const (
+	EXIT_OK = 0
+	// an unexpected error
+	EXIT_ERROR = 1
+	// invalid arguments or input
+	EXIT_VALIDATION = 2
+	// RSpace URL or API key missing, or the API key was rejected
+	EXIT_AUTH = 3
+	// a requested item does not exist
+	EXIT_NOT_FOUND = 4
+	// RSpace could not be reached
+	EXIT_NETWORK = 5
+	// a batch command completed, but some items failed
+	EXIT_PARTIAL_FAILURE = 6
+	// an item was changed by someone else while being updated
+	EXIT_CONFLICT = 7
+)
+
+// help text listing the exit codes
+const exitCodesHelp = `Exit codes:
+  0  success
+  1  unexpected error
+  2  invalid arguments or input
+  3  authentication failed, or no URL or API key configured
+  4  item not found
+  5  network error, RSpace could not be reached
+  6  partial failure - some items of a batch command failed
+  7  conflict - an item was changed by someone else while being updated`
+
+var exitCodeTypes = map[int]string{
+	EXIT_ERROR:           "error",
+	EXIT_VALIDATION:      "validation",
+	EXIT_AUTH:            "auth",
+	EXIT_NOT_FOUND:       "notFound",
+	EXIT_NETWORK:         "network",
+	EXIT_PARTIAL_FAILURE: "partialFailure",
+	EXIT_CONFLICT:        "conflict",
+}
+
+// cliError is an error with a known exit code
+type cliError struct {
+	Code int
+	Err  error
+}
+
+func (e *cliError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.Err
+}
+
+func newCliError(code int, message string) error {
+	return &cliError{code, errors.New(message)}
+}
+
+var authErrorRegexp = regexp.MustCompile(`(?i)\b(401|403)\b|unauthori[sz]ed|forbidden|api key`)
+var notFoundErrorRegexp = regexp.MustCompile(`(?i)\b404\b|not found|no such file`)
+var conflictErrorRegexp = regexp.MustCompile(`(?i)\b409\b|conflict`)
+
+// exitCodeFor classifies an error. RSpace API errors are classified from the HTTP status in their message.
+func exitCodeFor(err error) int {
+	if err == nil {
+		return EXIT_OK
+	}
+	var cliErr *cliError
+	if errors.As(err, &cliErr) {
+		return cliErr.Code
+	}
+	// checked first, as file errors can look like network errors
+	if errors.Is(err, os.ErrNotExist) {
+		return EXIT_NOT_FOUND
+	}
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return EXIT_NETWORK
+	}
+	message := err.Error()
+	switch {
+	case authErrorRegexp.MatchString(message):
+		return EXIT_AUTH
+	case notFoundErrorRegexp.MatchString(message):
+		return EXIT_NOT_FOUND
+	case conflictErrorRegexp.MatchString(message):
+		return EXIT_CONFLICT
+	}
+	return EXIT_ERROR
+}
+
+// jsonError is how errors are written when JSON output is requested
+type jsonError struct {
+	Item  string          `json:"item,omitempty"`
+	Error jsonErrorDetail `json:"error"`
+}
+
+type jsonErrorDetail struct {
+	Code    int    `json:"code"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func newJsonError(item string, code int, message string) jsonError {
+	return jsonError{item, jsonErrorDetail{code, exitCodeTypes[code], message}}
+}
+
+// writeError writes an error as a JSON object on a single line if the output format is JSON,
+// or as text otherwise
+func writeError(writer io.Writer, format outputFmt, item string, code int, message string) {
+	if format.isJson() || format.isJsonLines() {
+		bytes, _ := json.Marshal(newJsonError(item, code, message))
+		fmt.Fprintln(writer, string(bytes))
+	} else if len(item) > 0 {
+		fmt.Fprintf(writer, "%s: %s\n", item, message)
+	} else {
+		fmt.Fprintln(writer, message)
+	}
+}
+
+// reportItemFailure reports that one item of a batch command failed, and the command carried on.
+// It's safe to call from concurrent goroutines.
+func (ctx *Context) reportItemFailure(item string, err error) {
+	ctx.errMutex.Lock()
+	defer ctx.errMutex.Unlock()
+	ctx.ItemFailures++
+	writeError(ctx.errWriter(), ctx.Format, item, exitCodeFor(err), err.Error())
+}
+
+// itemFailuresError is returned by batch commands once results have been written,
+// if any items failed
+func (ctx *Context) itemFailuresError() error {
+	if ctx.ItemFailures > 0 {
+		return newCliError(EXIT_PARTIAL_FAILURE, fmt.Sprintf("%d item(s) failed", ctx.ItemFailures))
+	}
+	return nil
+}
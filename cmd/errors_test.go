@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	assertIdEquals(t, exitCodeFor(newCliError(EXIT_VALIDATION, "bad argument")), EXIT_VALIDATION)
+	wrapped := fmt.Errorf("listing: %w", newCliError(EXIT_NOT_FOUND, "none"))
+	assertIdEquals(t, exitCodeFor(wrapped), EXIT_NOT_FOUND)
+	urlErr := &url.Error{Op: "Get", URL: "https://myrspace.org/api/v1/status", Err: errors.New("connection refused")}
+	assertIdEquals(t, exitCodeFor(urlErr), EXIT_NETWORK)
+	assertIdEquals(t, exitCodeFor(errors.New("Status: 401 Unauthorized")), EXIT_AUTH)
+	assertIdEquals(t, exitCodeFor(errors.New("Status: 404 - Document SD12 not found")), EXIT_NOT_FOUND)
+	_, err := os.Open("does/not/exist.txt")
+	assertIdEquals(t, exitCodeFor(err), EXIT_NOT_FOUND)
+	assertIdEquals(t, exitCodeFor(errors.New("Status: 409 Conflict")), EXIT_CONFLICT)
+	assertIdEquals(t, exitCodeFor(errors.New("Status: 500 Internal server error")), EXIT_ERROR)
+}
+
+func TestWriteErrorAsJson(t *testing.T) {
+	out := &bytes.Buffer{}
+	writeError(out, outputFmt("json"), "SD12", EXIT_NOT_FOUND, "Document not found")
+	assertEqualString(t, `{"item":"SD12","error":{"code":4,"type":"notFound","message":"Document not found"}}`+"\n", out.String())
+
+	out.Reset()
+	writeError(out, outputFmt("table"), "SD12", EXIT_NOT_FOUND, "Document not found")
+	assertEqualString(t, "SD12: Document not found\n", out.String())
+}
+
+func TestItemFailuresAreWrittenInTheContextFormat(t *testing.T) {
+	ctx, _, _, errOut := newMemoryContext("jsonl")
+	ctx.reportItemFailure("row 2", newCliError(EXIT_VALIDATION, "No name"))
+	assertEqualString(t, `{"item":"row 2","error":{"code":2,"type":"validation","message":"No name"}}`+"\n", errOut.String())
+
+	ctx, _, _, errOut = newMemoryContext("csv")
+	ctx.reportItemFailure("row 2", newCliError(EXIT_VALIDATION, "No name"))
+	assertEqualString(t, "row 2: No name\n", errOut.String())
+}
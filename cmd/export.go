@@ -65,16 +65,25 @@ rspace eln export SD123 NB456 FL789 --format html --scope selection
 // export - don't include linked documents. You can use numeric IDs if you prefer.
 rspace eln export 123 456 --linkDepth 0
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// initial wait for job might take some time
-		ctx := initialiseContextWithTimeout(1200)
-		exportArgs(ctx, args)
+		ctx, err := initialiseContextWithTimeout(1200)
+		if err != nil {
+			return err
+		}
+		return exportArgs(ctx, args)
 	},
 }
 
-func exportArgs(ctx *Context, args []string) {
-	scope := getExportScope(exportCmdArgsArg.Scope)
-	format := getExportFormat(exportCmdArgsArg.Format)
+func exportArgs(ctx *Context, args []string) error {
+	scope, err := getExportScope(exportCmdArgsArg.Scope)
+	if err != nil {
+		return err
+	}
+	format, err := getExportFormat(exportCmdArgsArg.Format)
+	if err != nil {
+		return err
+	}
 	var itemIds []int
 	var userOrGroupId int
 	if len(args) > 0 {
@@ -82,32 +91,24 @@ func exportArgs(ctx *Context, args []string) {
 			itemIds = globalIdListToIntList(args)
 		} else {
 			if len(args) > 1 {
-				exitWithStdErrMsg("Only a single user or group id is supported")
+				return newCliError(EXIT_VALIDATION, "Only a single user or group id is supported")
 			}
-			var err error
 			userOrGroupId, err = strconv.Atoi(args[0])
 			if err != nil {
-				exitWithErr(err)
+				return newCliError(EXIT_VALIDATION, fmt.Sprintf("%s is not a valid user or group id", args[0]))
 			}
 		}
 	}
 	post := rspace.ExportPost{format, scope, userOrGroupId, itemIds, exportCmdArgsArg.MaxLinkLevel}
-	messageStdErr("Waiting for export to start...")
-	if exportCmdArgsArg.Wait {
-		result, err := ctx.WebClient.Export(post, true, messageStdErr)
-		if err != nil {
-			exitWithErr(err)
-		}
-		if result.IsCompleted() {
-			ctx.writeResult(&JobFormatter{result})
-		}
-	} else {
-		result, err := ctx.WebClient.Export(post, false, messageStdErr)
-		if err != nil {
-			exitWithErr(err)
-		}
-		ctx.writeResult(&JobFormatter{result})
+	ctx.messageStdErr("Waiting for export to start...")
+	result, err := ctx.WebClient.Export(post, exportCmdArgsArg.Wait, ctx.messageStdErr)
+	if err != nil {
+		return err
+	}
+	if exportCmdArgsArg.Wait && !result.IsCompleted() {
+		return nil
 	}
+	return ctx.writeResult(&JobFormatter{result})
 }
 
 type JobFormatter struct {
@@ -139,28 +140,26 @@ func (fs *JobFormatter) ToJson() string {
 	return prettyMarshal(fs.Job)
 }
 
-func getExportFormat(format string) rspace.ExportFormat {
+func getExportFormat(format string) (rspace.ExportFormat, error) {
 	switch format {
 	case "xml":
-		return rspace.XML_FORMAT
+		return rspace.XML_FORMAT, nil
 	case "html":
-		return rspace.HTML_FORMAT
+		return rspace.HTML_FORMAT, nil
 	}
-	exitWithStdErrMsg("export format must be 'xml' or 'html'")
-	return 0
+	return 0, newCliError(EXIT_VALIDATION, "export format must be 'xml' or 'html'")
 }
 
-func getExportScope(arg string) rspace.ExportScope {
+func getExportScope(arg string) (rspace.ExportScope, error) {
 	switch arg {
 	case "user":
-		return rspace.USER_EXPORT_SCOPE
+		return rspace.USER_EXPORT_SCOPE, nil
 	case "group":
-		return rspace.GROUP_EXPORT_SCOPE
+		return rspace.GROUP_EXPORT_SCOPE, nil
 	case "selection":
-		return rspace.SELECTION_EXPORT_SCOPE
+		return rspace.SELECTION_EXPORT_SCOPE, nil
 	}
-	exitWithStdErrMsg("export scope must be 'user' or 'group'")
-	return 0
+	return 0, newCliError(EXIT_VALIDATION, "export scope must be 'user', 'group' or 'selection'")
 }
 
 func init() {
@@ -17,15 +17,16 @@ type scannedFileInfo struct {
 }
 
 // validates that file paths entered as command line arguments are readable
-func validateInputFilePaths(paths []string) {
+func validateInputFilePaths(paths []string) error {
 	for _, filePath := range paths {
 		var err error
 		filePath, err = filepath.Abs(filePath)
 		_, err = os.Stat(filePath)
 		if err != nil {
-			exitWithErr(err)
+			return err
 		}
 	}
+	return nil
 }
 
 // filters scannedFileInfo
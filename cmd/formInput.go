@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+// layout of Date field values
+const FORM_DATE_LAYOUT = "2006-01-02"
+
+// formMapping maps the columns of input data to the fields of a form
+type formMapping struct {
+	form    *rspace.Form
+	headers []string
+	// index of the form field that each column maps to, or -1 if the column is ignored
+	fieldIndexes []int
+}
+
+// newFormMapping matches column headers to form field names, ignoring case. If a mapping of column
+// names to field names or numbers is supplied, that is used instead, and unmapped columns are ignored.
+// Ignored columns supply other data, such as tags, and aren't reported if they don't match a field.
+func newFormMapping(form *rspace.Form, headers []string, mapping map[string]string, ignored ...string) (*formMapping, error) {
+	fm := &formMapping{form: form, headers: headers, fieldIndexes: make([]int, len(headers))}
+	if mapping != nil {
+		return fm, fm.applyMapping(mapping)
+	}
+	unknown := make([]string, 0)
+	mapped := make(map[int]string)
+	for i, header := range headers {
+		index := fm.fieldIndex(header)
+		fm.fieldIndexes[i] = index
+		if index < 0 {
+			if !containsIgnoringCase(ignored, header) {
+				unknown = append(unknown, header)
+			}
+			continue
+		}
+		if column, seen := mapped[index]; seen {
+			return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("Columns '%s' and '%s' both map to field '%s'",
+				column, header, form.Fields[index].Name))
+		}
+		mapped[index] = header
+	}
+	missing := make([]string, 0)
+	for i, field := range form.Fields {
+		if _, ok := mapped[i]; !ok {
+			missing = append(missing, field.Name)
+		}
+	}
+	problems := make([]string, 0)
+	if len(unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("Unknown column(s): '%s'", strings.Join(unknown, "', '")))
+	}
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("No column(s) for field(s): '%s'", strings.Join(missing, "', '")))
+	}
+	if len(problems) > 0 {
+		return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("%s.\nForm %s has fields '%s'. Rename the columns to match, or use --mapping",
+			strings.Join(problems, ". "), form.GlobalId, strings.Join(fm.fieldNames(), "', '")))
+	}
+	return fm, nil
+}
+
+func (fm *formMapping) applyMapping(mapping map[string]string) error {
+	for i := range fm.fieldIndexes {
+		fm.fieldIndexes[i] = -1
+	}
+	columns := make([]string, 0)
+	for column := range mapping {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	mapped := make(map[int]string)
+	for _, column := range columns {
+		fieldRef := mapping[column]
+		columnIndex := fm.columnIndex(column)
+		if columnIndex < 0 {
+			return newCliError(EXIT_VALIDATION, fmt.Sprintf("Mapped column '%s' is not in the input", column))
+		}
+		fieldIndex := fm.fieldIndex(fieldRef)
+		// fields can also be identified by number, counting from 1
+		if number, err := strconv.Atoi(fieldRef); err == nil && number >= 1 && number <= len(fm.form.Fields) {
+			fieldIndex = number - 1
+		}
+		if fieldIndex < 0 {
+			return newCliError(EXIT_VALIDATION, fmt.Sprintf("Column '%s' is mapped to '%s', which is not a field of form %s. Fields are '%s'",
+				column, fieldRef, fm.form.GlobalId, strings.Join(fm.fieldNames(), "', '")))
+		}
+		if other, seen := mapped[fieldIndex]; seen {
+			return newCliError(EXIT_VALIDATION, fmt.Sprintf("Columns '%s' and '%s' are both mapped to field '%s'",
+				other, column, fm.form.Fields[fieldIndex].Name))
+		}
+		mapped[fieldIndex] = column
+		fm.fieldIndexes[columnIndex] = fieldIndex
+	}
+	return nil
+}
+
+// columnIndex finds a column by its header, ignoring case, returning -1 if not found
+func (fm *formMapping) columnIndex(name string) int {
+	for i, header := range fm.headers {
+		if strings.EqualFold(strings.TrimSpace(header), strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowValues maps the column headers to the values of a row
+func (fm *formMapping) rowValues(row []string) map[string]string {
+	values := make(map[string]string)
+	for i, header := range fm.headers {
+		if i < len(row) {
+			values[strings.TrimSpace(header)] = strings.TrimSpace(row[i])
+		} else {
+			values[strings.TrimSpace(header)] = ""
+		}
+	}
+	return values
+}
+
+// fieldIndex finds a form field by name, ignoring case, returning -1 if not found
+func (fm *formMapping) fieldIndex(name string) int {
+	for i, field := range fm.form.Fields {
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(field.Name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (fm *formMapping) fieldNames() []string {
+	names := make([]string, 0)
+	for _, field := range fm.form.Fields {
+		names = append(names, field.Name)
+	}
+	return names
+}
+
+// fieldContent validates a row of input and orders its values as the form's fields. Unmapped fields are empty.
+func (fm *formMapping) fieldContent(row []string) ([]rspace.FieldContent, error) {
+	content := make([]rspace.FieldContent, len(fm.form.Fields))
+	problems := make([]string, 0)
+	for i, value := range row {
+		if i >= len(fm.fieldIndexes) || fm.fieldIndexes[i] < 0 {
+			continue
+		}
+		field := fm.form.Fields[fm.fieldIndexes[i]]
+		if err := validateFieldValue(field, value); err != nil {
+			problems = append(problems, fmt.Sprintf("column '%s': %s", fm.headers[i], err.Error()))
+		}
+		content[fm.fieldIndexes[i]].Content = value
+	}
+	if len(problems) > 0 {
+		return nil, newCliError(EXIT_VALIDATION, strings.Join(problems, "; "))
+	}
+	return content, nil
+}
+
+// validateFieldValue checks that a value suits the type of a field. Empty values are always valid.
+// Choice fields can have several values, separated by commas.
+func validateFieldValue(field rspace.FormField, value string) error {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return nil
+	}
+	switch strings.ToLower(field.Type) {
+	case "number":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New(fmt.Sprintf("'%s' is not a number", value))
+		}
+		min, hasMin, max, hasMax := numberLimits(field)
+		if hasMin && number < min {
+			return errors.New(fmt.Sprintf("%s is less than the minimum of %v", value, min))
+		}
+		if hasMax && number > max {
+			return errors.New(fmt.Sprintf("%s is more than the maximum of %v", value, max))
+		}
+	case "date":
+		date, err := time.Parse(FORM_DATE_LAYOUT, value)
+		if err != nil {
+			return errors.New(fmt.Sprintf("'%s' is not a date in the format yyyy-mm-dd", value))
+		}
+		if limit, ok := dateLimit(field.Min); ok && date.Before(limit) {
+			return errors.New(fmt.Sprintf("%s is before the earliest date of %s", value, limit.Format(FORM_DATE_LAYOUT)))
+		}
+		if limit, ok := dateLimit(field.Max); ok && date.After(limit) {
+			return errors.New(fmt.Sprintf("%s is after the latest date of %s", value, limit.Format(FORM_DATE_LAYOUT)))
+		}
+	case "choice", "radio":
+		options := field.Options
+		chosen := []string{value}
+		if strings.ToLower(field.Type) == "choice" {
+			chosen = strings.Split(value, ",")
+			if len(chosen) > 1 && !field.Multiple {
+				return errors.New(fmt.Sprintf("only 1 option can be chosen, but was '%s'", value))
+			}
+		}
+		for _, option := range chosen {
+			if len(options) > 0 && !validateArrayContains(options, []string{strings.TrimSpace(option)}) {
+				return errors.New(fmt.Sprintf("'%s' is not one of the options '%s'", strings.TrimSpace(option), strings.Join(options, "', '")))
+			}
+		}
+	}
+	return nil
+}
+
+func containsIgnoringCase(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), strings.TrimSpace(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// numberLimits returns a number field's minimum and maximum, if it has them. Limits that are
+// missing are unbounded; any limit that is set, including 0, is a bound.
+func numberLimits(field rspace.FormField) (float64, bool, float64, bool) {
+	min, hasMin := numberLimit(field.Min)
+	max, hasMax := numberLimit(field.Max)
+	return min, hasMin, max, hasMax
+}
+
+// numberLimit reads a limit, which is missing if nil or empty
+func numberLimit(limit interface{}) (float64, bool) {
+	switch v := limit.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func dateLimit(limit interface{}) (time.Time, bool) {
+	s, ok := limit.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(FORM_DATE_LAYOUT, s)
+	return date, err == nil
+}
+
+// readKeyValueFile reads lines of 'key=value', e.g. 'column=field' lines mapping columns to fields, where
+// lineFormat describes them. Blank lines and lines starting with '#' are ignored.
+func readKeyValueFile(path, lineFormat string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 || len(strings.TrimSpace(parts[1])) == 0 {
+			return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("Line %d of %s should be '%s', but was '%s'", lineNumber, path, lineFormat, line))
+		}
+		values[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+// creates a form with a field of each type that has its values validated
+func addSampleForm(client *memoryRSpace) *rspace.Form {
+	amount := memoryFormField("Amount", "Number")
+	amount.Min, amount.Max = 0, 100
+	stain := memoryFormField("Stains", "Choice")
+	stain.Options, stain.Multiple = []string{"red", "blue", "green"}, true
+	state := memoryFormField("State", "Radio")
+	state.Options = []string{"solid", "liquid"}
+	fields := []rspace.FormField{memoryFormField("Sample", "Text"), amount, memoryFormField("Date", "Date"), stain, state}
+	return client.addForm("Sample", fields)
+}
+
+func writeTestCsv(t *testing.T, content string) (string, func()) {
+	dir, _ := ioutil.TempDir("", "formInput")
+	path := filepath.Join(dir, "input.csv")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Couldn't write CSV: %v", err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestFormMappingMatchesHeadersInAnyOrder(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	form := addSampleForm(client)
+	input, cleanup := writeTestCsv(t, "state,Date,SAMPLE,Stains,Amount\nliquid,2021-03-01,s1,\"red,blue\",12.5\n")
+	defer cleanup()
+	err := doAddDocRun(addDocArgs{NameArg: "sample", FormId: form.GlobalId, InputData: input}, ctx, client)
+	if err != nil {
+		t.Fatalf("Expected document to be created but got %v", err)
+	}
+	if len(client.documents) != 1 {
+		t.Fatalf("Expected 1 document but got %d", len(client.documents))
+	}
+	content := make([]string, 0)
+	for _, field := range client.documents[0].Fields {
+		content = append(content, field.Content)
+	}
+	assertEqualString(t, "s1|12.5|2021-03-01|red,blue|liquid", strings.Join(content, "|"))
+}
+
+func TestFormMappingReportsUnknownAndMissingColumns(t *testing.T) {
+	client := newMemoryRSpace()
+	form := addSampleForm(client)
+	_, err := newFormMapping(form, []string{"Sample", "Amount", "Colour", "Date", "State"}, nil)
+	if exitCodeFor(err) != EXIT_VALIDATION {
+		t.Fatalf("Expected a validation error but got %v", err)
+	}
+	for _, expected := range []string{"Unknown column(s): 'Colour'", "No column(s) for field(s): 'Stains'", "--mapping"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q but was %q", expected, err.Error())
+		}
+	}
+	_, err = newFormMapping(form, []string{"Sample", "sample", "Amount", "Date", "Stains", "State"}, nil)
+	if exitCodeFor(err) != EXIT_VALIDATION {
+		t.Errorf("Expected duplicate columns to be rejected but got %v", err)
+	}
+}
+
+func TestFormMappingRejectsColumnsMappedToTheSameField(t *testing.T) {
+	form := addSampleForm(newMemoryRSpace())
+	headers := []string{"Name", "Label", "Amount", "Date", "Stains", "State"}
+	_, err := newFormMapping(form, headers, map[string]string{"Name": "Sample", "Label": "1", "Amount": "Amount"})
+	if exitCodeFor(err) != EXIT_VALIDATION || !strings.Contains(err.Error(), "'Label' and 'Name' are both mapped to field 'Sample'") {
+		t.Errorf("Expected columns mapped to the same field to be rejected but got %v", err)
+	}
+}
+
+func TestValidateFieldValue(t *testing.T) {
+	form := addSampleForm(newMemoryRSpace())
+	amount, date, stains, state := form.Fields[1], form.Fields[2], form.Fields[3], form.Fields[4]
+	single := stains
+	single.Multiple = false
+	valid := []struct {
+		field rspace.FormField
+		value string
+	}{{amount, "42"}, {amount, ""}, {date, "2021-12-31"}, {stains, "red, green"}, {single, "blue"}, {state, "solid"}}
+	for _, v := range valid {
+		if err := validateFieldValue(v.field, v.value); err != nil {
+			t.Errorf("Expected '%s' to be valid for %s but got %v", v.value, v.field.Name, err)
+		}
+	}
+	invalid := []struct {
+		field rspace.FormField
+		value string
+	}{{amount, "lots"}, {amount, "101"}, {amount, "-1"}, {date, "31/12/2021"}, {stains, "red,pink"},
+		{single, "red,blue"}, {state, "gas"}}
+	for _, v := range invalid {
+		if err := validateFieldValue(v.field, v.value); err == nil {
+			t.Errorf("Expected '%s' to be invalid for %s", v.value, v.field.Name)
+		}
+	}
+}
+
+func TestValidateUnboundedNumberField(t *testing.T) {
+	unset := memoryFormField("Count", "Number")
+	for _, value := range []string{"-12.5", "0", "1000000"} {
+		if err := validateFieldValue(unset, value); err != nil {
+			t.Errorf("Expected %s to be valid for a field without limits but got %v", value, err)
+		}
+	}
+	minOnly := memoryFormField("Count", "Number")
+	minOnly.Min = 5
+	if err := validateFieldValue(minOnly, "1000"); err != nil {
+		t.Errorf("Expected a field with only a minimum to have no maximum but got %v", err)
+	}
+	if err := validateFieldValue(minOnly, "4"); err == nil {
+		t.Errorf("Expected 4 to be less than the minimum")
+	}
+}
+
+func TestValidateZeroNumberLimits(t *testing.T) {
+	cases := []struct {
+		min, max       interface{}
+		valid, invalid []string
+	}{
+		{0.0, 0.0, []string{"0", "0.0"}, []string{"-1", "0.5"}},
+		{-10, 0, []string{"-10", "-5", "0"}, []string{"0.1", "-11"}},
+		{0, 100, []string{"0", "100"}, []string{"-0.5", "101"}},
+		{0, nil, []string{"0", "1000000"}, []string{"-1"}},
+		{nil, 0, []string{"-1000000", "0"}, []string{"1"}},
+		{5, 0, []string{}, []string{"0", "5", "6"}},
+	}
+	for _, c := range cases {
+		field := memoryFormField("Count", "Number")
+		field.Min, field.Max = c.min, c.max
+		for _, value := range c.valid {
+			if err := validateFieldValue(field, value); err != nil {
+				t.Errorf("Expected %s to be valid between %v and %v but got %v", value, c.min, c.max, err)
+			}
+		}
+		for _, value := range c.invalid {
+			if err := validateFieldValue(field, value); err == nil {
+				t.Errorf("Expected %s to be invalid between %v and %v", value, c.min, c.max)
+			}
+		}
+	}
+}
+
+func TestInvalidRowsAreReportedAndSkipped(t *testing.T) {
+	ctx, client, _, errOut := newMemoryContext("quiet")
+	form := addSampleForm(client)
+	input, cleanup := writeTestCsv(t, "Sample,Amount,Date,Stains,State\ns1,1,2021-03-01,red,solid\ns2,x,2021-03-01,red,solid\n")
+	defer cleanup()
+	err := doAddDocRun(addDocArgs{NameArg: "sample", FormId: form.GlobalId, InputData: input}, ctx, client)
+	if exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected partial failure but got %v", err)
+	}
+	if len(client.documents) != 1 || !strings.Contains(errOut.String(), "row 2") {
+		t.Errorf("Expected row 2 to be skipped but created %d documents, and reported %s", len(client.documents), errOut.String())
+	}
+}
+
+func TestAddDocumentWithMappingFile(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	fields := make([]rspace.FormField, 0)
+	for _, name := range []string{"Date", "Aim", "Outcome"} {
+		fields = append(fields, memoryFormField(name, "Text"))
+	}
+	form := client.addForm("Experiment", fields)
+	err := doAddDocRun(addDocArgs{NameArg: "run", FormId: form.GlobalId, InputData: "testData/ExperimentData.csv",
+		MappingFile: "testData/ExperimentMapping.txt"}, ctx, client)
+	if err != nil {
+		t.Fatalf("Expected documents to be created but got %v", err)
+	}
+	if len(client.documents) != 2 {
+		t.Fatalf("Expected 2 documents but got %d", len(client.documents))
+	}
+	fieldValues := client.documents[1].Fields
+	assertEqualString(t, "some onbjectives2", fieldValues[1].Content)
+	assertEqualString(t, "some results2", fieldValues[2].Content)
+}
+
+func createdNames(client *memoryRSpace) string {
+	names := make([]string, 0)
+	for _, doc := range client.documents {
+		names = append(names, doc.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestDocumentsFromFileAreNumberedByRow(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	form := addSampleForm(client)
+	input, cleanup := writeTestCsv(t, "Sample,Amount,Date,Stains,State\ns1,1,,,\ns2,2,,,\ns3,3,,,\n")
+	defer cleanup()
+	if err := doAddDocRun(addDocArgs{NameArg: "run", FormId: form.GlobalId, InputData: input}, ctx, client); err != nil {
+		t.Fatalf("Expected documents to be created but got %v", err)
+	}
+	assertEqualString(t, "run,run-2,run-3", createdNames(client))
+}
+
+func TestDocumentsFromFileWithoutNameAreNamedAfterTheFile(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	form := addExperimentForm(client)
+	if err := doAddDocRun(addDocArgs{FormId: form.GlobalId, InputData: "testData/ExperimentData.csv"}, ctx, client); err != nil {
+		t.Fatalf("Expected documents to be created but got %v", err)
+	}
+	assertEqualString(t, "ExperimentData,ExperimentData-2", createdNames(client))
+}
+
+func TestNameTemplateAndTagsColumn(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	form := addSampleForm(client)
+	input, cleanup := writeTestCsv(t, "Sample,Amount,Date,Stains,State,Keywords\n"+
+		"s1,1,2021-03-01,,,\"pcr, dna\"\ns2,2,2021-03-02,,,\n")
+	defer cleanup()
+	args := addDocArgs{FormId: form.GlobalId, InputData: input, Tags: "batch1",
+		NameTemplate: "{{.Date}}-{{.Sample}}", TagsColumn: "keywords"}
+	if err := doAddDocRun(args, ctx, client); err != nil {
+		t.Fatalf("Expected documents to be created but got %v", err)
+	}
+	assertEqualString(t, "2021-03-01-s1,2021-03-02-s2", createdNames(client))
+	assertEqualString(t, "batch1,pcr,dna", client.documents[0].Tags)
+	assertEqualString(t, "batch1", client.documents[1].Tags)
+}
+
+func TestNameTemplateAndTagsColumnMustMatchColumns(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	form := addSampleForm(client)
+	input, cleanup := writeTestCsv(t, "Sample,Amount,Date,Stains,State\ns1,1,,,\n")
+	defer cleanup()
+	for _, args := range []addDocArgs{
+		addDocArgs{NameTemplate: "{{.Sampel}}"},
+		addDocArgs{NameTemplate: "{{.Sample"},
+		addDocArgs{TagsColumn: "Keywords"},
+	} {
+		args.FormId, args.InputData = form.GlobalId, input
+		if err := doAddDocRun(args, ctx, client); exitCodeFor(err) != EXIT_VALIDATION {
+			t.Errorf("Expected a validation error for %+v but got %v", args, err)
+		}
+	}
+	if len(client.documents) != 0 {
+		t.Errorf("Expected no documents to be created")
+	}
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JSON Lines, YAML, TSV and Markdown output are all derived from a formatter's JSON or table output,
+// so every formatter supports them without further work.
+
+// jsonLines writes each result of a list formatter as a compact JSON object on its own line.
+// Formatters that don't expose their results are written as one line, or a line per item
+// if their JSON is a list.
+func jsonLines(formatter ResultListFormatter) ([]string, error) {
+	lines := make([]string, 0)
+	if lister, ok := formatter.(resultItems); ok {
+		for _, item := range lister.items() {
+			line, err := json.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, string(line))
+		}
+		return lines, nil
+	}
+	items, err := jsonItems([]byte(formatter.ToJson()))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		compacted := &bytes.Buffer{}
+		if err := json.Compact(compacted, item); err != nil {
+			return nil, err
+		}
+		lines = append(lines, compacted.String())
+	}
+	return lines, nil
+}
+
+// jsonItems splits JSON into the items of a list, or else returns the single value
+func jsonItems(content []byte) ([]json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return []json.RawMessage{}, nil
+	}
+	if trimmed[0] == '[' {
+		items := make([]json.RawMessage, 0)
+		err := json.Unmarshal(trimmed, &items)
+		return items, err
+	}
+	return []json.RawMessage{trimmed}, nil
+}
+
+// jsonNode is a JSON value that keeps the order of object keys
+type jsonNode struct {
+	scalar interface{}
+	keys   []string
+	values []*jsonNode
+	isMap  bool
+	isList bool
+}
+
+func parseJsonNode(decoder *json.Decoder) (*jsonNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	node := &jsonNode{}
+	switch t := token.(type) {
+	case json.Delim:
+		node.isMap = t == '{'
+		node.isList = t == '['
+		for decoder.More() {
+			if node.isMap {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, keyToken.(string))
+			}
+			child, err := parseJsonNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, child)
+		}
+		// consume closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	default:
+		node.scalar = t
+	}
+	return node, nil
+}
+
+// jsonToYaml converts the JSON of a result to block-style YAML, keeping the order of fields
+func jsonToYaml(jsonStr string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(jsonStr))
+	decoder.UseNumber()
+	root, err := parseJsonNode(decoder)
+	if err == io.EOF {
+		return "null", nil
+	} else if err != nil {
+		return "", errors.New(fmt.Sprintf("Could not convert to YAML: %s", err.Error()))
+	}
+	lines := make([]string, 0)
+	writeYamlNode(root, 0, &lines)
+	return strings.Join(lines, "\n"), nil
+}
+
+func writeYamlNode(node *jsonNode, indent int, lines *[]string) {
+	pad := strings.Repeat(" ", indent)
+	switch {
+	case node.isMap && len(node.keys) > 0:
+		for i, key := range node.keys {
+			child := node.values[i]
+			if isYamlInline(child) {
+				*lines = append(*lines, pad+yamlString(key)+": "+yamlInline(child))
+			} else {
+				*lines = append(*lines, pad+yamlString(key)+":")
+				writeYamlNode(child, indent+2, lines)
+			}
+		}
+	case node.isList && len(node.values) > 0:
+		for _, child := range node.values {
+			if isYamlInline(child) {
+				*lines = append(*lines, pad+"- "+yamlInline(child))
+				continue
+			}
+			// nested content goes on the same line as the '-' marker
+			childLines := make([]string, 0)
+			writeYamlNode(child, indent+2, &childLines)
+			childLines[0] = pad + "- " + strings.TrimPrefix(childLines[0], pad+"  ")
+			*lines = append(*lines, childLines...)
+		}
+	default:
+		*lines = append(*lines, pad+yamlInline(node))
+	}
+}
+
+func isYamlInline(node *jsonNode) bool {
+	return (!node.isMap && !node.isList) || len(node.values) == 0
+}
+
+func yamlInline(node *jsonNode) string {
+	switch {
+	case node.isMap:
+		return "{}"
+	case node.isList:
+		return "[]"
+	}
+	switch v := node.scalar.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	}
+	return fmt.Sprintf("%v", node.scalar)
+}
+
+var yamlPlainRegexp = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_ ./()+-]*$`)
+var yamlReservedRegexp = regexp.MustCompile(`^(?i)(true|false|yes|no|on|off|y|n|null|~)$`)
+
+// strings are written unquoted only if they can't be mistaken for another type
+func yamlString(s string) string {
+	if yamlPlainRegexp.MatchString(s) && !yamlReservedRegexp.MatchString(s) && !strings.HasSuffix(s, " ") {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// tsvCell replaces characters that would break a row of tab-separated values
+func tsvCell(cell string) string {
+	return strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ").Replace(cell)
+}
+
+func tsvRows(table *TableResult) []string {
+	rows := make([]string, 0)
+	for _, row := range table.Content {
+		cells := make([]string, 0)
+		for _, cell := range row {
+			cells = append(cells, tsvCell(cell))
+		}
+		rows = append(rows, strings.Join(cells, "\t"))
+	}
+	return rows
+}
+
+func printTsv(ctx *Context, table *TableResult) {
+	ctx.write(strings.Join(columnDefsToString(table.Headers), "\t"))
+	for _, row := range tsvRows(table) {
+		ctx.write(row)
+	}
+}
+
+func printJsonLines(ctx *Context, formatter ResultListFormatter) error {
+	lines, err := jsonLines(formatter)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		ctx.write(line)
+	}
+	return nil
+}
+
+func printYaml(ctx *Context, jsonStr string) error {
+	yaml, err := jsonToYaml(jsonStr)
+	if err != nil {
+		return err
+	}
+	ctx.write(yaml)
+	return nil
+}
+
+// markdownCell escapes characters that would break a Markdown table
+func markdownCell(cell string) string {
+	cell = strings.ReplaceAll(cell, `\`, `\\`)
+	cell = strings.ReplaceAll(cell, "|", `\|`)
+	cell = strings.ReplaceAll(cell, "\r\n", "<br>")
+	return strings.ReplaceAll(cell, "\n", "<br>")
+}
+
+func markdownRow(cells []string) string {
+	escaped := make([]string, 0)
+	for _, cell := range cells {
+		escaped = append(escaped, markdownCell(cell))
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
+
+func printMarkdownHeaders(ctx *Context, headers []columnDef) {
+	ctx.write(markdownRow(columnDefsToString(headers)))
+	separators := make([]string, 0)
+	for range headers {
+		separators = append(separators, "---")
+	}
+	ctx.write("|" + strings.Join(separators, "|") + "|")
+}
+
+func printMarkdownRows(ctx *Context, rows [][]string) {
+	for _, row := range rows {
+		ctx.write(markdownRow(row))
+	}
+}
+
+func printMarkdown(ctx *Context, table *TableResult) {
+	printMarkdownHeaders(ctx, table.Headers)
+	printMarkdownRows(ctx, table.Content)
+}
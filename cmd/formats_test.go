@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+func TestJsonLines(t *testing.T) {
+	page := docPage()
+	page.Documents = append(page.Documents, page.Documents[0])
+	lines, err := jsonLines(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"Id":12,"GlobalId":"SD12","Name":"PCR run"`) {
+		t.Fatalf("Expected a line per document but got %v", lines)
+	}
+
+	// a single result is one line, however many lists it holds
+	namable := &rspace.IdentifiableNamable{Id: 1, GlobalId: "SD1", Name: "doc"}
+	doc := &rspace.Document{DocumentInfo: &rspace.DocumentInfo{IdentifiableNamable: namable},
+		Fields: []rspace.Field{{Name: "Data", Content: "a"}, {Name: "Notes", Content: "b"}}}
+	lines, _ = jsonLines(&DocumentFormatter{doc})
+	if len(lines) != 1 || !strings.Contains(lines[0], `"GlobalId":"SD1"`) || !strings.Contains(lines[0], `"Notes"`) {
+		t.Fatalf("Expected the document on 1 line but got %v", lines)
+	}
+
+	// formatters without an item list are split if their JSON is a list
+	lines, _ = jsonLines(&queryFormatter{[]interface{}{"a", "b", "c"}})
+	assertEqualString(t, `"a","b","c"`, strings.Join(lines, ","))
+	lines, _ = jsonLines(&queryFormatter{map[string]interface{}{"Tags": []string{"a"}, "Files": []string{}}})
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 line but got %v", lines)
+	}
+}
+
+func TestJsonToYaml(t *testing.T) {
+	yaml, err := jsonToYaml(`{"Name": "PCR run", "Id": 12, "Tags": ["a", "b"], "Owner": {"Username": "user1", "Enabled": true},
+		"Created": "2020-05-20T10:00:00Z", "Fields": [{"Name": "yes", "Content": ""}], "Empty": [], "Form": null}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `Name: PCR run
+Id: 12
+Tags:
+  - a
+  - b
+Owner:
+  Username: user1
+  Enabled: true
+Created: "2020-05-20T10:00:00Z"
+Fields:
+  - Name: "yes"
+    Content: ""
+Empty: []
+Form: null`
+	assertEqualString(t, expected, yaml)
+}
+
+func TestWriteResultTsv(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("tsv")}
+	ctx.writeResult(formPage(1, "tab\tin name"))
+	out := ctx.Writer.(*bytes.Buffer).String()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 2 || strings.Count(lines[1], "\t") != 4 || !strings.Contains(lines[1], "tab in name") {
+		t.Fatalf("Unexpected TSV output %q", out)
+	}
+}
+
+func TestStreamJsonLines(t *testing.T) {
+	out := streamPages("jsonl", 10, formPage(3, "a", "b"), formPage(3, "c"))
+	if strings.Count(out, "\n") != 3 || !strings.HasPrefix(out, "{") {
+		t.Fatalf("Expected one line per form but got %s", out)
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, format := range validOutputFormats {
+		if !validateOutputFormat(outputFmt(format)) {
+			t.Errorf("%s should be a valid output format", format)
+		}
+	}
+	if validateOutputFormat(outputFmt("xml")) {
+		t.Errorf("xml is not a valid output format")
+	}
+}
+
+func TestInvalidFlagsAreReturnedAsErrors(t *testing.T) {
+	defer func(format, outFile, filter string) {
+		outputFormatArg, outFileArg, treeFilterArg = format, outFile, filter
+	}(outputFormatArg, outFileArg, treeFilterArg)
+	cases := []struct{ format, outFile, filter, message string }{
+		{"xml", "", "", "Invalid outputFormat argument"},
+		{"xlsx", "", "", "--outFile"},
+		{"json", "", "document,page", "Invalid tree filter"},
+	}
+	for _, c := range cases {
+		outputFormatArg, outFileArg, treeFilterArg = c.format, c.outFile, c.filter
+		ctx, err := initialiseLocalContext()
+		if ctx != nil || exitCodeFor(err) != EXIT_VALIDATION || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("Expected %+v to be rejected but got %v", c, err)
+		}
+	}
+}
+
+func TestMarkdownOutput(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("markdown")}
+	ctx.writeResult(formPage(1, "a|b"))
+	expected := "| Id | GlobalId | Name | StableId | PublishingState |\n|---|---|---|---|---|\n| 1 | FM1 | a\\|b |  |  |\n"
+	assertEqualString(t, expected, ctx.Writer.(*bytes.Buffer).String())
+}
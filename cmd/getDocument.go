@@ -0,0 +1,114 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+	"github.com/spf13/cobra"
+)
+
+var getDocumentCmd = &cobra.Command{
+	Use:   "getDocument",
+	Short: "Shows a document with all its fields",
+	Long: `Show a document's details - name, tags, form, owner and dates - and the content of all its fields.
+
+In table, csv and other tabular formats, each detail and field is shown as a row.
+	`,
+	Aliases: []string{"showDocument", "docGet"},
+	Example: `
+// show a document
+rspace eln getDocument SD123
+
+// get the full document as JSON
+rspace eln getDocument 123 -f json
+
+// get the content of the 2nd field
+rspace eln getDocument SD123 -f json --jmespath 'Fields[1].Content'
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doGetDocument(ctx, ctx.WebClient, args[0])
+	},
+}
+
+func doGetDocument(ctx *Context, docClient DocEditClient, idArg string) error {
+	id, err := documentIdFromArg(idArg)
+	if err != nil {
+		return err
+	}
+	doc, err := docClient.DocumentById(id)
+	if err != nil {
+		return err
+	}
+	return ctx.writeResult(&DocumentFormatter{doc})
+}
+
+// documentIdFromArg parses a document id, e.g. 'SD123' or '123'
+func documentIdFromArg(idArg string) (int, error) {
+	id, err := idFromGlobalId(idArg)
+	if err != nil || id == 0 {
+		return 0, newCliError(EXIT_VALIDATION, fmt.Sprintf("%s is not a valid document id", idArg))
+	}
+	return id, nil
+}
+
+// DocumentFormatter shows a document's details and fields, one per row in tabular formats
+type DocumentFormatter struct {
+	*rspace.Document
+}
+
+func (df *DocumentFormatter) ToJson() string {
+	return prettyMarshal(df.Document)
+}
+
+func (df *DocumentFormatter) ToQuiet() []identifiable {
+	return []identifiable{identifiable{strconv.Itoa(df.Id)}}
+}
+
+func (df *DocumentFormatter) ToTable() *TableResult {
+	doc := df.Document
+	rows := [][]string{
+		[]string{"Id", strconv.Itoa(doc.Id)},
+		[]string{"GlobalId", doc.GlobalId},
+		[]string{"Name", doc.Name},
+		[]string{"Tags", doc.Tags},
+		[]string{"Form", fmt.Sprintf("%s (%s)", doc.Form.Name, doc.Form.GlobalId)},
+		[]string{"Owner", doc.UserInfo.Username},
+		[]string{"Created", doc.Created},
+		[]string{"Last Modified", doc.LastModified},
+	}
+	maxLabelWidth := 15
+	for i, field := range doc.Fields {
+		label := fmt.Sprintf("%d: %s (%s)", i+1, field.Name, field.Type)
+		if len(label) > maxLabelWidth {
+			maxLabelWidth = len(label)
+		}
+		rows = append(rows, []string{label, field.Content})
+	}
+	headers := []columnDef{columnDef{"Property", maxLabelWidth}, columnDef{"Value", 50}}
+	return &TableResult{headers, rows}
+}
+
+func init() {
+	elnCmd.AddCommand(getDocumentCmd)
+}
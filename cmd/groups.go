@@ -32,21 +32,24 @@ var listGroupsCmd = &cobra.Command{
 rspace eln groups
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
-		doListGroups(context)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doListGroups(context)
 	},
 }
 
-func doListGroups(ctx *Context) {
+func doListGroups(ctx *Context) error {
 	var groupList *rspace.GroupList
 	var err error
 	groupList, err = ctx.WebClient.Groups()
 	if err != nil {
-		exitWithErr(err)
+		return err
 	}
 	formatter := &GroupListFormatter{groupList}
-	ctx.writeResult(formatter)
+	return ctx.writeResult(formatter)
 }
 
 type GroupListFormatter struct {
@@ -57,6 +60,14 @@ func (fs *GroupListFormatter) ToJson() string {
 	return prettyMarshal(fs.GroupList.Groups)
 }
 
+func (fs *GroupListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, group := range fs.GroupList.Groups {
+		rc = append(rc, group)
+	}
+	return rc
+}
+
 func (ds *GroupListFormatter) ToQuiet() []identifiable {
 	rows := make([]identifiable, 0)
 	for _, res := range ds.GroupList.Groups {
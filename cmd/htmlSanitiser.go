@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HTML content is checked and cleaned before it's put in a document, so that it only uses the elements
+// and attributes that RSpace's editor supports. Scripts, frames and other active content are removed,
+// as are event handlers and 'javascript:' URLs. Other problems, such as unclosed tags and images with
+// relative paths, which RSpace can't find, are reported as warnings.
+
+// elements that the RSpace editor supports
+var htmlAllowedElements = toSet("a", "abbr", "address", "b", "bdo", "big", "blockquote", "br", "caption", "center", "cite",
+	"code", "col", "colgroup", "dd", "del", "dfn", "div", "dl", "dt", "em", "figcaption", "figure", "font", "h1", "h2",
+	"h3", "h4", "h5", "h6", "hr", "i", "img", "ins", "kbd", "li", "mark", "ol", "p", "pre", "q", "s", "samp", "small",
+	"span", "strike", "strong", "sub", "sup", "table", "tbody", "td", "tfoot", "th", "thead", "tr", "tt", "u", "ul", "var")
+
+// elements that are removed along with their content
+var htmlRemovedElements = toSet("script", "iframe", "frame", "frameset", "object", "embed", "applet", "style",
+	"noscript", "template", "form", "svg", "math", "audio", "video", "canvas")
+
+// elements of a whole HTML page, that are removed without warning
+var htmlPageElements = toSet("html", "body", "head", "title", "meta", "link", "base")
+
+var htmlVoidElements = toSet("area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "param", "source", "track", "wbr")
+
+// elements whose end tags can be left out
+var htmlOptionalEndElements = toSet("p", "li", "dt", "dd", "tr", "td", "th", "thead", "tbody", "tfoot", "colgroup", "caption")
+
+// the open elements, with optional end tags, that are implicitly closed by a start tag
+var htmlImpliedEndElements = map[string]map[string]bool{
+	"li":    toSet("li", "p"),
+	"dt":    toSet("dt", "dd", "p"),
+	"dd":    toSet("dt", "dd", "p"),
+	"td":    toSet("td", "th"),
+	"th":    toSet("td", "th"),
+	"tr":    toSet("tr", "td", "th"),
+	"thead": toSet("thead", "tbody", "tfoot", "tr", "td", "th", "caption", "colgroup"),
+	"tbody": toSet("thead", "tbody", "tfoot", "tr", "td", "th", "caption", "colgroup"),
+	"tfoot": toSet("thead", "tbody", "tfoot", "tr", "td", "th", "caption", "colgroup"),
+}
+
+// block elements, that implicitly close an open paragraph
+var htmlBlockElements = toSet("p", "div", "ul", "ol", "dl", "table", "blockquote", "pre", "hr",
+	"h1", "h2", "h3", "h4", "h5", "h6")
+
+var htmlAllowedAttributes = toSet("align", "alt", "border", "cellpadding", "cellspacing", "class", "color", "colspan",
+	"dir", "face", "height", "href", "id", "lang", "name", "rel", "rowspan", "size", "span", "src", "start", "style",
+	"target", "title", "type", "valign", "width")
+
+var htmlSafeUrlRegexp = regexp.MustCompile(`(?i)^(https?:|ftp:|mailto:|[^:]*$|[^:/?#]*[/?#])`)
+var htmlUrlSchemeRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]+:`)
+var htmlUnsafeStyleRegexp = regexp.MustCompile(`(?i)expression\s*\(|javascript:|vbscript:|url\s*\(|behavior\s*:|-moz-binding`)
+
+// RSpace's syntax for linking to a Gallery file, e.g. <fileId=1234>. Anything else is an ordinary tag.
+var htmlFileIdLinkRegexp = regexp.MustCompile(`^<fileId=\d+>$`)
+
+func toSet(items ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+type htmlToken struct {
+	kind        int
+	name        string
+	attrs       []html.Attribute
+	selfClosing bool
+	text        string
+}
+
+const (
+	htmlText = iota
+	htmlStartTag
+	htmlEndTag
+	// a fileId link, or other text passed through verbatim
+	htmlVerbatim
+)
+
+// tokenizeHtml splits HTML into text and tags. Comments, doctypes, CDATA sections and
+// processing instructions are dropped.
+func tokenizeHtml(s string) []htmlToken {
+	tokens := make([]htmlToken, 0)
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tokenType := z.Next()
+		if tokenType == html.ErrorToken {
+			// the end of the content, or an unfinished tag at the end, which is kept as text
+			if raw := string(z.Raw()); len(raw) > 0 {
+				tokens = append(tokens, htmlToken{kind: htmlText, text: raw})
+			}
+			return tokens
+		}
+		raw := string(z.Raw())
+		token := z.Token()
+		switch tokenType {
+		case html.TextToken:
+			// text is kept as written, so that entities aren't changed
+			tokens = append(tokens, htmlToken{kind: htmlText, text: raw})
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if htmlFileIdLinkRegexp.MatchString(raw) {
+				tokens = append(tokens, htmlToken{kind: htmlVerbatim, text: raw})
+				continue
+			}
+			tokens = append(tokens, htmlToken{kind: htmlStartTag, name: token.Data, attrs: token.Attr,
+				selfClosing: tokenType == html.SelfClosingTagToken})
+		case html.EndTagToken:
+			tokens = append(tokens, htmlToken{kind: htmlEndTag, name: token.Data})
+		}
+	}
+}
+
+// htmlSanitiser rebuilds HTML from its tokens, keeping only what's allowed
+type htmlSanitiser struct {
+	out      *strings.Builder
+	open     []string
+	warnings []string
+}
+
+func (h *htmlSanitiser) warn(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	if !validateArrayContains(h.warnings, []string{warning}) {
+		h.warnings = append(h.warnings, warning)
+	}
+}
+
+// sanitiseHtml returns the content with only the elements and attributes the RSpace editor supports,
+// and warnings of what was changed, or may not work in RSpace
+func sanitiseHtml(content string) (string, []string) {
+	h := &htmlSanitiser{out: &strings.Builder{}}
+	tokens := tokenizeHtml(content)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.kind {
+		case htmlText:
+			h.out.WriteString(strings.ReplaceAll(token.text, "<", "&lt;"))
+		case htmlVerbatim:
+			h.out.WriteString(token.text)
+		case htmlEndTag:
+			h.closeElement(token.name)
+		case htmlStartTag:
+			switch {
+			case htmlRemovedElements[token.name] || token.name == "head" || token.name == "title":
+				if !htmlPageElements[token.name] {
+					h.warn("Removed <%s> element(s)", token.name)
+				}
+				i = skipElement(tokens, i)
+			case htmlPageElements[token.name]:
+			case !htmlAllowedElements[token.name]:
+				h.warn("Removed <%s> tag(s), which RSpace doesn't support", token.name)
+			default:
+				h.startElement(token)
+			}
+		}
+	}
+	for len(h.open) > 0 {
+		name := h.open[len(h.open)-1]
+		if !htmlOptionalEndElements[name] {
+			h.warn("Closed unclosed <%s> tag(s)", name)
+		}
+		h.popElement()
+	}
+	return h.out.String(), h.warnings
+}
+
+// skipElement returns the index of the end tag of the element starting at tokens[start]
+func skipElement(tokens []htmlToken, start int) int {
+	name := tokens[start].name
+	if tokens[start].selfClosing || htmlVoidElements[name] {
+		return start
+	}
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		if tokens[i].name != name {
+			continue
+		}
+		if tokens[i].kind == htmlStartTag {
+			depth++
+		} else if tokens[i].kind == htmlEndTag {
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens)
+}
+
+func (h *htmlSanitiser) startElement(token htmlToken) {
+	for len(h.open) > 0 {
+		top := h.open[len(h.open)-1]
+		if !htmlImpliedEndElements[token.name][top] && !(top == "p" && htmlBlockElements[token.name]) {
+			break
+		}
+		h.popElement()
+	}
+	h.out.WriteString("<" + token.name)
+	for _, attr := range token.attrs {
+		if !h.allowAttribute(token.name, attr) {
+			continue
+		}
+		h.out.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if htmlVoidElements[token.name] {
+		h.out.WriteString(" />")
+		return
+	}
+	h.out.WriteString(">")
+	if token.selfClosing {
+		h.out.WriteString("</" + token.name + ">")
+		return
+	}
+	h.open = append(h.open, token.name)
+}
+
+func (h *htmlSanitiser) allowAttribute(element string, attr html.Attribute) bool {
+	switch {
+	case strings.HasPrefix(attr.Key, "on"):
+		h.warn("Removed '%s' event handler(s)", attr.Key)
+		return false
+	case strings.HasPrefix(attr.Key, "data-"):
+		return true
+	case !htmlAllowedAttributes[attr.Key]:
+		h.warn("Removed '%s' attribute(s) of <%s>", attr.Key, element)
+		return false
+	case attr.Key == "href" || attr.Key == "src":
+		url := strings.TrimSpace(attr.Val)
+		if element == "img" && attr.Key == "src" && strings.HasPrefix(strings.ToLower(url), "data:image/") {
+			return true
+		}
+		if !htmlSafeUrlRegexp.MatchString(url) {
+			h.warn("Removed unsafe URL '%s' from <%s>", abbreviate(url, 40), element)
+			return false
+		}
+		if element == "img" && attr.Key == "src" && !htmlUrlSchemeRegexp.MatchString(url) && !strings.HasPrefix(url, "/") {
+			h.warn("Image '%s' has a relative path, so won't be found by RSpace. Upload it to the Gallery, and link to it with <fileId=ID>", url)
+		}
+	case attr.Key == "style" && htmlUnsafeStyleRegexp.MatchString(attr.Val):
+		h.warn("Removed unsafe style '%s' from <%s>", abbreviate(attr.Val, 40), element)
+		return false
+	}
+	return true
+}
+
+// checkHtmlContent sanitises HTML content, writing warnings of any changes. In strict mode,
+// content that needs changing is refused instead.
+func checkHtmlContent(ctx *Context, content string, strict bool) (string, error) {
+	sanitised, warnings := sanitiseHtml(content)
+	if len(warnings) > 0 && strict {
+		return "", newCliError(EXIT_VALIDATION, fmt.Sprintf("Content is not valid for RSpace:\n  %s", strings.Join(warnings, "\n  ")))
+	}
+	for _, warning := range warnings {
+		ctx.messageStdErr("Warning: " + warning)
+	}
+	return sanitised, nil
+}
+
+// closeElement closes the most recent open element with a name, and any elements opened since then
+func (h *htmlSanitiser) closeElement(name string) {
+	index := -1
+	for i := len(h.open) - 1; i >= 0; i-- {
+		if h.open[i] == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		if htmlAllowedElements[name] && !htmlVoidElements[name] {
+			h.warn("Removed </%s> tag(s) without an opening tag", name)
+		}
+		return
+	}
+	for len(h.open) > index+1 {
+		inner := h.open[len(h.open)-1]
+		if !htmlOptionalEndElements[inner] {
+			h.warn("Closed <%s> tag(s) that weren't closed before </%s>", inner, name)
+		}
+		h.popElement()
+	}
+	h.popElement()
+}
+
+func (h *htmlSanitiser) popElement() {
+	h.out.WriteString("</" + h.open[len(h.open)-1] + ">")
+	h.open = h.open[:len(h.open)-1]
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitiseHtml(t *testing.T) {
+	cases := []struct{ content, sanitised, warning string }{
+		{"<p>Some <b>bold</b> text<br>and <fileId=123></p>", "<p>Some <b>bold</b> text<br />and <fileId=123></p>", ""},
+		{"<!DOCTYPE html><html><head><title>Notes</title><style>p {}</style></head><body><p>x</p></body></html>", "<p>x</p>", ""},
+		{"<ul><li>a<li>b</ul><table><tr><td>1<td>2</table>", "<ul><li>a</li><li>b</li></ul><table><tr><td>1</td><td>2</td></tr></table>", ""},
+		{"<fileId=12 onmouseover=\"alert(1)\" style=\"position:fixed\">x", "x", "Removed <fileid=12> tag(s), which RSpace doesn't support"},
+		{"<p>a</p><script>alert('</p>')</script><p>b</p>", "<p>a</p><p>b</p>", "Removed <script> element(s)"},
+		{"<div><iframe src=\"https://evil.com\"></iframe>ok</div>", "<div>ok</div>", "Removed <iframe> element(s)"},
+		{"<p onclick=\"steal()\" class=\"note\">x</p>", "<p class=\"note\">x</p>", "Removed 'onclick' event handler(s)"},
+		{"<a href=\"JavaScript:steal()\">x</a><a href=\"/globalId/SD1\">y</a>", "<a>x</a><a href=\"/globalId/SD1\">y</a>", "Removed unsafe URL 'JavaScript:steal()' from <a>"},
+		{"<p style=\"background: url(x)\">x</p>", "<p>x</p>", "Removed unsafe style"},
+		{"<img src=\"images/gel.png\" alt=\"gel\">", "<img src=\"images/gel.png\" alt=\"gel\" />", "Image 'images/gel.png' has a relative path"},
+		{"<img src=\"https://a.b/gel.png\"><img src=\"/image/1\">", "<img src=\"https://a.b/gel.png\" /><img src=\"/image/1\" />", ""},
+		{"<blink>new</blink> <input value=\"1\">", "new ", "Removed <blink> tag(s), which RSpace doesn't support"},
+		{"<div><span>unclosed</div>", "<div><span>unclosed</span></div>", "Closed <span> tag(s) that weren't closed before </div>"},
+		{"<div>end", "<div>end</div>", "Closed unclosed <div> tag(s)"},
+		{"text</b>", "text", "Removed </b> tag(s) without an opening tag"},
+		{"1 < 2 and 3 <4", "1 &lt; 2 and 3 &lt;4", ""},
+		{"<p title='a \"quote\"' data-id=5>x</p>", "<p title=\"a &#34;quote&#34;\" data-id=\"5\">x</p>", ""},
+		{"<P CLASS=note Title = \"t\"><B>x</B></P>", "<p class=\"note\" title=\"t\"><b>x</b></p>", ""},
+		{"<td colspan=2><a href=javascript:steal()>x</a>", "<td colspan=\"2\"><a>x</a></td>", "Removed unsafe URL"},
+		{"<img src=\"x\"onerror=\"alert(1)\">", "<img src=\"x\" />", "Removed 'onerror' event handler(s)"},
+		// CDATA sections are bogus comments in HTML, ending at the first '>'
+		{"a<!-- <script>alert(1)</script> -->b<![CDATA[<script>]]>c", "ab]]>c", ""},
+		{"<p>&lt;b&gt; &amp; &nbsp;</p>", "<p>&lt;b&gt; &amp; &nbsp;</p>", ""},
+	}
+	for _, c := range cases {
+		sanitised, warnings := sanitiseHtml(c.content)
+		if sanitised != c.sanitised {
+			t.Errorf("Expected %q to be sanitised as\n%q but was\n%q", c.content, c.sanitised, sanitised)
+		}
+		joined := strings.Join(warnings, "\n")
+		if (len(c.warning) == 0 && len(warnings) > 0) || !strings.Contains(joined, c.warning) {
+			t.Errorf("Expected warning %q for %q but got %q", c.warning, c.content, joined)
+		}
+	}
+}
+
+func TestAddDocumentWarnsOrRefusesInvalidHtml(t *testing.T) {
+	ctx, client, _, errOut := newMemoryContext("quiet")
+	content := "<p>Results</p><script>alert(1)</script>"
+	if err := doAddDocRun(addDocArgs{NameArg: "warned", Content: content}, ctx, client); err != nil {
+		t.Fatalf("Expected document to be created but got %v", err)
+	}
+	assertEqualString(t, "<p>Results</p>", client.documents[0].Fields[0].Content)
+	if !strings.Contains(errOut.String(), "Warning: Removed <script> element(s)") {
+		t.Errorf("Expected a warning but got %s", errOut.String())
+	}
+
+	err := doAddDocRun(addDocArgs{NameArg: "refused", Content: content, Strict: true}, ctx, client)
+	if exitCodeFor(err) != EXIT_VALIDATION || !strings.Contains(err.Error(), "<script>") {
+		t.Errorf("Expected invalid content to be refused but got %v", err)
+	}
+	if err = doAddDocRun(addDocArgs{NameArg: "valid", Content: "<p>ok</p>", Strict: true}, ctx, client); err != nil {
+		t.Errorf("Expected valid content to be accepted but got %v", err)
+	}
+	assertEqualString(t, "warned,valid", createdNames(client))
+}
@@ -96,15 +96,20 @@ rspace eln importWord wordfile.docx
 rspace eln importWord AFolder --folder 1234 --recursive
 	`,
 	Args: cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := initialiseContext()
-		importArgs(ctx, args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return importArgs(ctx, args)
 	},
 }
 
-func importArgs(ctx *Context, args []string) {
+func importArgs(ctx *Context, args []string) error {
 	// fail fast if files can't be read
-	validateInputFilePaths(args)
+	if err := validateInputFilePaths(args); err != nil {
+		return err
+	}
 	filesToUpload := scanFiles(args, importArgsArg.RecursiveFlag, acceptMsDoc())
 
 	messageStdErr(fmt.Sprintf("Found %d files to import - total amount to import is %s", len(filesToUpload),
@@ -116,7 +121,10 @@ func importArgs(ctx *Context, args []string) {
 			importedDocs = append(importedDocs, docInfo)
 		}
 	}
-	reportImport(ctx, importedDocs)
+	if err := reportImport(ctx, importedDocs); err != nil {
+		return err
+	}
+	return ctx.itemFailuresError()
 }
 
 type DocArrayList struct {
@@ -131,6 +139,14 @@ func (fmt *DocArrayListFormatter) ToJson() string {
 	return prettyMarshal(fmt.DocArrayList.docList)
 }
 
+func (fmt *DocArrayListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, doc := range fmt.DocArrayList.docList {
+		rc = append(rc, doc)
+	}
+	return rc
+}
+
 func (fmt *DocArrayListFormatter) ToQuiet() []identifiable {
 	return toIdentifiableDoc(fmt.DocArrayList.docList)
 }
@@ -161,17 +177,17 @@ func toIdentifiableDoc(results []*rspace.DocumentInfo) []identifiable {
 	return rows
 }
 
-func reportImport(ctx *Context, uploaded []*rspace.DocumentInfo) {
+func reportImport(ctx *Context, uploaded []*rspace.DocumentInfo) error {
 	if importArgsArg.DryrunFlag {
 		messageStdErr(fmt.Sprintf("File upload would upload %d files", len(uploaded)))
-		return
+		return nil
 	}
 	messageStdErr(fmt.Sprintf("reportImporting %d results:", len(uploaded)))
 
 	var dal DocArrayList = DocArrayList{uploaded}
 	//TODO FIX THIS, implement DocListFormatter
 	var formatter DocArrayListFormatter = DocArrayListFormatter{dal}
-	ctx.writeResult(&formatter)
+	return ctx.writeResult(&formatter)
 }
 
 func importDocListToBaseInfoList(results []*rspace.FileInfo) []rspace.BasicInfo {
@@ -192,7 +208,7 @@ func importFile(ctx *Context, fileInfo *scannedFileInfo) *rspace.DocumentInfo {
 	doc, err := ctx.WebClient.ImportWord(filePath, importArgsArg.TargetFolder, 0)
 	if err != nil {
 		// other files might upload OK, so don't exit here
-		messageStdErr(err.Error())
+		ctx.reportItemFailure(filePath, err)
 	} else {
 		fileInfo.Uploaded = true
 	}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Input data for creating documents is read as a table: a row of headers, then a row per document.
+// CSV and XLSX files are already tables. JSON, JSON Lines and YAML files hold a list of objects
+// keyed by field name; the headers are all the keys, in the order they're first seen.
+
+var inputDataFormats = []string{"csv", "json", "jsonl", "yaml", "xlsx"}
+
+// inputDataFormat is the format given by --inputFormat, or else by the file's extension
+func inputDataFormat(path, formatArg string) (string, error) {
+	format := strings.ToLower(formatArg)
+	if len(format) == 0 {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch format {
+	case "", "txt":
+		return "csv", nil
+	case "ndjson":
+		return "jsonl", nil
+	case "yml":
+		return "yaml", nil
+	}
+	if !validateArrayContains(inputDataFormats, []string{format}) {
+		return "", newCliError(EXIT_VALIDATION, fmt.Sprintf("Can't read input data in '%s' format. Supported formats are %s",
+			format, strings.Join(inputDataFormats, ", ")))
+	}
+	return format, nil
+}
+
+// readInputTable reads a file of input data. For XLSX, sheet is a sheet name or number, or the 1st sheet if empty.
+func readInputTable(path, formatArg, sheet string) ([][]string, error) {
+	format, err := inputDataFormat(path, formatArg)
+	if err != nil {
+		return nil, err
+	}
+	if len(sheet) > 0 && format != "xlsx" {
+		return nil, newCliError(EXIT_VALIDATION, "--sheet can only be used with XLSX input")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var table [][]string
+	switch format {
+	case "xlsx":
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		table, err = readXlsxSheet(f, info.Size(), sheet)
+		if err != nil {
+			return nil, inputError(path, err)
+		}
+	case "csv":
+		table, err = readCsvFile(f)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		records, err := readRecords(f, format)
+		if err != nil {
+			return nil, inputError(path, err)
+		}
+		if table, err = recordsToTable(records); err != nil {
+			return nil, inputError(path, err)
+		}
+	}
+	if err = validateCsvInput(table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func inputError(path string, err error) error {
+	if _, ok := err.(*cliError); ok {
+		return err
+	}
+	return newCliError(EXIT_VALIDATION, fmt.Sprintf("Couldn't read %s: %s", path, err.Error()))
+}
+
+// readRecords reads the list of objects in JSON, JSON Lines or YAML input
+func readRecords(in io.Reader, format string) ([]*jsonNode, error) {
+	if format == "jsonl" {
+		records := make([]*jsonNode, 0)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for lineNumber := 1; scanner.Scan(); lineNumber++ {
+			line := strings.TrimSpace(scanner.Text())
+			if len(line) == 0 {
+				continue
+			}
+			record, err := parseJsonInput([]byte(line))
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("line %d: %s", lineNumber, err.Error()))
+			}
+			records = append(records, record)
+		}
+		return records, scanner.Err()
+	}
+	content, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	var root *jsonNode
+	if format == "yaml" {
+		root, err = parseYaml(string(content))
+	} else {
+		root, err = parseJsonInput(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return recordList(root)
+}
+
+func parseJsonInput(content []byte) (*jsonNode, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	node, err := parseJsonNode(decoder)
+	if err == io.EOF {
+		return &jsonNode{isList: true}, nil
+	}
+	return node, err
+}
+
+// recordList gets the records from a list, a single object, or an object holding a single list
+func recordList(root *jsonNode) ([]*jsonNode, error) {
+	if root.isList {
+		return root.values, nil
+	}
+	if root.isMap {
+		lists := make([]*jsonNode, 0)
+		for _, value := range root.values {
+			if value.isList {
+				lists = append(lists, value)
+			}
+		}
+		if len(lists) == 1 && len(root.values) == 1 {
+			return lists[0].values, nil
+		}
+		return []*jsonNode{root}, nil
+	}
+	return nil, errors.New("expected a list of objects keyed by field name")
+}
+
+// recordsToTable converts records to a table with a column for every key
+func recordsToTable(records []*jsonNode) ([][]string, error) {
+	headers := make([]string, 0)
+	columns := make(map[string]int)
+	for i, record := range records {
+		if !record.isMap {
+			return nil, errors.New(fmt.Sprintf("item %d is not an object keyed by field name", i+1))
+		}
+		for _, key := range record.keys {
+			if _, seen := columns[key]; !seen {
+				columns[key] = len(headers)
+				headers = append(headers, key)
+			}
+		}
+	}
+	table := [][]string{headers}
+	for i, record := range records {
+		row := make([]string, len(headers))
+		for j, key := range record.keys {
+			value, err := recordValue(record.values[j])
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("item %d, '%s': %s", i+1, key, err.Error()))
+			}
+			row[columns[key]] = value
+		}
+		table = append(table, row)
+	}
+	return table, nil
+}
+
+// recordValue converts a value to text. Lists, e.g. of choices, are comma-separated.
+func recordValue(node *jsonNode) (string, error) {
+	switch {
+	case node.isMap:
+		return "", errors.New("nested objects can't be used as field values")
+	case node.isList:
+		items := make([]string, 0)
+		for _, child := range node.values {
+			if child.isMap || child.isList {
+				return "", errors.New("lists can only hold simple values")
+			}
+			item, _ := recordValue(child)
+			items = append(items, item)
+		}
+		return strings.Join(items, ","), nil
+	}
+	switch v := node.scalar.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return fmt.Sprintf("%v", node.scalar), nil
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// the same samples as CSV, for each input format
+var sampleInputs = map[string]string{
+	"json": `[{"Sample": "s1", "Amount": 12.5, "Date": "2021-03-01", "Stains": ["red", "blue"], "State": "liquid"},
+		{"Sample": "s2", "Amount": 3, "Date": null, "Stains": [], "State": "solid"}]`,
+	"jsonl": `{"State": "liquid", "Sample": "s1", "Amount": 12.5, "Date": "2021-03-01", "Stains": "red,blue"}
+
+{"Sample": "s2", "Amount": 3, "State": "solid"}`,
+	"yaml": `# samples from the plate reader
+---
+- Sample: s1
+  Amount: 12.5
+  Date: "2021-03-01"
+  Stains: [red, blue]  # both stains
+  State: liquid
+- Sample: 's2'
+  Amount: 3
+  Stains:
+  State: solid
+`,
+}
+
+func writeTestInput(t *testing.T, name string, content []byte) (string, func()) {
+	dir, _ := ioutil.TempDir("", "inputData")
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("Couldn't write input: %v", err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func documentFieldContent(client *memoryRSpace) string {
+	docs := make([]string, 0)
+	for _, doc := range client.documents {
+		content := make([]string, 0)
+		for _, field := range doc.Fields {
+			content = append(content, field.Content)
+		}
+		docs = append(docs, doc.Name+":"+strings.Join(content, "|"))
+	}
+	return strings.Join(docs, "\n")
+}
+
+func TestAddDocumentsFromEachInputFormat(t *testing.T) {
+	expected := "sample:s1|12.5|2021-03-01|red,blue|liquid\nsample-2:s2|3|||solid"
+	for format, content := range sampleInputs {
+		ctx, client, _, _ := newMemoryContext("quiet")
+		form := addSampleForm(client)
+		input, cleanup := writeTestInput(t, "samples."+format, []byte(content))
+		defer cleanup()
+		if err := doAddDocRun(addDocArgs{NameArg: "sample", FormId: form.GlobalId, InputData: input}, ctx, client); err != nil {
+			t.Errorf("Expected documents from %s but got %v", format, err)
+			continue
+		}
+		if got := documentFieldContent(client); got != expected {
+			t.Errorf("Expected documents from %s to be\n%s\nbut were\n%s", format, expected, got)
+		}
+	}
+}
+
+func TestInputFormatOverridesExtension(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	form := addSampleForm(client)
+	input, cleanup := writeTestInput(t, "samples.txt", []byte(sampleInputs["json"]))
+	defer cleanup()
+	args := addDocArgs{NameArg: "sample", FormId: form.GlobalId, InputData: input, InputDataFormat: "JSON"}
+	if err := doAddDocRun(args, ctx, client); err != nil || len(client.documents) != 2 {
+		t.Errorf("Expected 2 documents from JSON input but got %d, %v", len(client.documents), err)
+	}
+	for _, args := range []addDocArgs{
+		addDocArgs{InputData: input, InputDataFormat: "xls"},
+		addDocArgs{InputData: input, InputDataFormat: "json", Sheet: "Samples"},
+	} {
+		args.FormId = form.GlobalId
+		if err := doAddDocRun(args, ctx, client); exitCodeFor(err) != EXIT_VALIDATION {
+			t.Errorf("Expected a validation error for %+v but got %v", args, err)
+		}
+	}
+}
+
+func TestInvalidRecordsAreRejected(t *testing.T) {
+	for _, content := range []string{`[{"Sample": {"id": 1}}]`, `["s1", "s2"]`, `[{"Sample": "s1"`, `42`} {
+		records, err := readRecords(strings.NewReader(content), "json")
+		if err == nil {
+			_, err = recordsToTable(records)
+		}
+		if err == nil {
+			t.Errorf("Expected %s to be rejected", content)
+		}
+	}
+}
+
+func TestParseYaml(t *testing.T) {
+	yaml := `documents:
+- name: "run \"1\""
+  notes: |
+    line 1
+      indented
+    line 3
+  summary: >-
+    folded
+    text
+  tags:
+    - a
+    - 'b''s'
+  empty: ~
+- name: "run: 2"
+  url: http://example.com/a#b
+`
+	root, err := parseYaml(yaml)
+	if err != nil {
+		t.Fatalf("Expected YAML to be parsed but got %v", err)
+	}
+	records, _ := recordList(root)
+	table, err := recordsToTable(records)
+	if err != nil {
+		t.Fatalf("Expected records but got %v", err)
+	}
+	assertEqualString(t, "name,notes,summary,tags,empty,url", strings.Join(table[0], ","))
+	assertEqualString(t, `run "1"`, table[1][0])
+	assertEqualString(t, "line 1\n  indented\nline 3\n", table[1][1])
+	assertEqualString(t, "folded text", table[1][2])
+	assertEqualString(t, "a,b's", table[1][3])
+	assertEqualString(t, "", table[1][4])
+	assertEqualString(t, "run: 2", table[2][0])
+	assertEqualString(t, "http://example.com/a#b", table[2][5])
+
+	// anchors, merge keys, flow mappings and YAML escapes
+	yaml = `defaults: &defaults {stain: "DAPI\tGFP", date: 2021-03-01}
+runs:
+  - <<: *defaults
+    sample: s1
+  - {<<: *defaults, sample: s2, date: 2021-03-02, amount: 1.50}
+`
+	root, err = parseYaml(yaml)
+	if err != nil {
+		t.Fatalf("Expected YAML to be parsed but got %v", err)
+	}
+	runs := root.values[1].values
+	table, err = recordsToTable(runs)
+	if err != nil {
+		t.Fatalf("Expected records but got %v", err)
+	}
+	assertEqualString(t, "sample,stain,date,amount", strings.Join(table[0], ","))
+	assertEqualString(t, "s1|DAPI\tGFP|2021-03-01|", strings.Join(table[1], "|"))
+	assertEqualString(t, "s2|DAPI\tGFP|2021-03-02|1.50", strings.Join(table[2], "|"))
+
+	for _, invalid := range []string{"- a: 1\n    b: 2", "a: [1, 2", "\t- a"} {
+		if _, err := parseYaml(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestYamlOutputCanBeReadAsInput(t *testing.T) {
+	json := `[{"name": "a: b", "count": 3, "ok": true, "tags": ["x", "y z"], "note": "say \"hi\"\n"}]`
+	yaml, err := jsonToYaml(json)
+	if err != nil {
+		t.Fatalf("Couldn't convert to YAML: %v", err)
+	}
+	records, err := readRecords(strings.NewReader(yaml), "yaml")
+	if err != nil {
+		t.Fatalf("Couldn't read YAML %s: %v", yaml, err)
+	}
+	table, _ := recordsToTable(records)
+	assertEqualString(t, "a: b|3|true|x,y z|say \"hi\"\n", strings.Join(table[1], "|"))
+}
+
+func TestReadXlsxWrittenByXlsxWriter(t *testing.T) {
+	var buf bytes.Buffer
+	headers := []columnDef{columnDef{"Sample", 10}, columnDef{"Amount", 6}, columnDef{"Date", 10}}
+	x, _ := newXlsxWriter(&buf, "Samples", headers)
+	x.writeHeaders(headers)
+	x.writeRows([][]string{{"s1", "0.1", "2021-03-01"}, {"007", "", "2021-03-01T10:30"}})
+	x.close()
+	for _, sheet := range []string{"", "samples", "1"} {
+		table, err := readXlsxSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()), sheet)
+		if err != nil {
+			t.Fatalf("Expected sheet '%s' to be read but got %v", sheet, err)
+		}
+		assertEqualString(t, "Sample,Amount,Date|s1,0.1,2021-03-01|007,,2021-03-01T10:30", joinTable(table))
+	}
+	_, err := readXlsxSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()), "Results")
+	if exitCodeFor(err) != EXIT_VALIDATION || !strings.Contains(err.Error(), "'Samples'") {
+		t.Errorf("Expected the sheets to be listed for a missing sheet but got %v", err)
+	}
+}
+
+func joinTable(table [][]string) string {
+	rows := make([]string, 0)
+	for _, row := range table {
+		rows = append(rows, strings.Join(row, ","))
+	}
+	return strings.Join(rows, "|")
+}
+
+// a workbook with shared strings, a custom date format, a number with units and a sparse 2nd sheet
+func excelWorkbook(t *testing.T) []byte {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetName("Sheet1", "Notes")
+	f.NewSheet("Samples")
+	dateFormat, mgFormat := "dd/mm/yyyy", `0.00" mg"`
+	dateStyle, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	mgStyle, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &mgFormat})
+	f.SetSheetRow("Samples", "A1", &[]interface{}{"Sample", "Date", nil, "Done"})
+	f.SetSheetRow("Samples", "A3", &[]interface{}{"s1", 44256, 0.1 + 0.2, true})
+	f.SetCellStyle("Samples", "B3", "B3", dateStyle)
+	f.SetCellStyle("Samples", "C3", "C3", mgStyle)
+	f.SetCellStyle("Samples", "A4", "A4", mgStyle)
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXlsxWrittenByExcel(t *testing.T) {
+	workbook := excelWorkbook(t)
+	table, err := readXlsxSheet(bytes.NewReader(workbook), int64(len(workbook)), "Samples")
+	if err != nil {
+		t.Fatalf("Expected sheet to be read but got %v", err)
+	}
+	assertEqualString(t, "Sample,Date,,Done|,,,|s1,2021-03-01,0.3,true", joinTable(table))
+
+	// an empty sheet has no rows of data
+	input, cleanup := writeTestInput(t, "samples.xlsx", workbook)
+	defer cleanup()
+	if _, err = readInputTable(input, "", "Notes"); err == nil {
+		t.Errorf("Expected an empty sheet to be rejected")
+	}
+}
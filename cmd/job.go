@@ -55,40 +55,44 @@ rspace eln job  22 --download --outfile /home/me/myexport.zip
 rspace eln job  22 --download
 	`,
 	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// initial wait for job might take some time
-		ctx := initialiseContext()
-		doJob(ctx, args)
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doJob(ctx, args)
 	},
 }
 
-func doJob(ctx *Context, args []string) {
+func doJob(ctx *Context, args []string) error {
 	id, e := strconv.Atoi(args[0])
 	if e != nil || id <= 0 {
-		exitWithStdErrMsg("Invalid job ID, must be an integer > 0, but was " + args[0])
+		return newCliError(EXIT_VALIDATION, "Invalid job ID, must be an integer > 0, but was "+args[0])
 	}
 	download := jobCmdArgsArg.Download
 	result, err := ctx.WebClient.GetJob(id)
 	if err != nil {
-		exitWithErr(err)
+		return err
+	}
+	if err := ctx.writeResult(&JobFormatter{result}); err != nil {
+		return err
 	}
-	ctx.writeResult(&JobFormatter{result})
 	if download {
 		if result.IsCompleted() {
 			downloadpath := getOutfile(result)
 			path, err := os.Create(downloadpath)
 			if err != nil {
-				exitWithErr(err)
-			}
-			messageStdErr(fmt.Sprintf("downloading to %s (%s)", downloadpath, humanizeBytes(uint64(result.Result.Size))))
-			err = ctx.WebClient.DownloadExport(result.DownloadLink(), path)
-			if err != nil {
-				exitWithErr(err)
+				return err
 			}
+			defer path.Close()
+			ctx.messageStdErr(fmt.Sprintf("downloading to %s (%s)", downloadpath, humanizeBytes(uint64(result.Result.Size))))
+			return ctx.WebClient.DownloadExport(result.DownloadLink(), path)
 		} else {
-			messageStdErr(fmt.Sprintf("Job %d not completed, nothing to download", result.Id))
+			ctx.messageStdErr(fmt.Sprintf("Job %d not completed, nothing to download", result.Id))
 		}
 	}
+	return nil
 }
 
 func getOutfile(job *rspace.Job) string {
@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -81,18 +82,24 @@ ids of documents.
 // get creation and write events for first 4 months of 2020
 rspace eln listActivity --actions create,write --afterDate 2020-01-01 --beforeDate 2020-05-01 
 
-// get full activity for a particular document
-rspace eln listACtivity --id SD12345
+// get full activity for a particular document, however many events there are
+rspace eln listACtivity --id SD12345 --all
 
 // get activity for a particular user (PIs and admins only)
 rspace eln listACtivity --users bob123, jacqueline
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
-		cfg, _ := configureActivityList()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		cfg, err := configureActivityList()
+		if err != nil {
+			return newCliError(EXIT_VALIDATION, err.Error())
+		}
 		pgCrit := configurePagination()
-		doListActivity(context, cfg, pgCrit)
+		return doListActivity(context, cfg, pgCrit)
 	},
 }
 
@@ -104,6 +111,20 @@ func (ds *ActivityListFormatter) ToJson() string {
 	return prettyMarshal(ds.ActivityList)
 }
 
+func (ds *ActivityListFormatter) appendPage(next StreamingFormatter) {
+	page := next.(*ActivityListFormatter).ActivityList
+	ds.Activities = append(ds.Activities, page.Activities...)
+	ds.TotalHits = page.TotalHits
+}
+
+func (ds *ActivityListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, activity := range ds.Activities {
+		rc = append(rc, activity)
+	}
+	return rc
+}
+
 func (ds *ActivityListFormatter) ToQuiet() []identifiable {
 	return toIdentifiableEvents(ds.ActivityList)
 }
@@ -141,14 +162,22 @@ func configureActivityList() (*rspace.ActivityQuery, error) {
 	return builder.Build()
 }
 
-func doListActivity(ctx *Context, cfg *rspace.ActivityQuery, pgcrit rspace.RecordListingConfig) {
-	var docList *rspace.ActivityList
-	var err error
-	docList, err = ctx.WebClient.Activities(cfg, pgcrit)
+func doListActivity(ctx *Context, cfg *rspace.ActivityQuery, pgcrit rspace.RecordListingConfig) error {
+	stream := ctx.newResultStream()
+	err := newPageIterator(ctx, pgcrit).forEachPage(func(pageCfg rspace.RecordListingConfig) (int, int, error) {
+		activityList, err := ctx.WebClient.Activities(cfg, pageCfg)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := stream.writePage(&ActivityListFormatter{activityList}); err != nil {
+			return 0, 0, err
+		}
+		return activityList.TotalHits, len(activityList.Activities), nil
+	})
 	if err != nil {
-		exitWithErr(err)
+		return err
 	}
-	ctx.writeResult(&ActivityListFormatter{docList})
+	return stream.close()
 }
 
 func toIdentifiableEvents(result *rspace.ActivityList) []identifiable {
@@ -167,7 +196,7 @@ func toIdentifiableEvents(result *rspace.ActivityList) []identifiable {
 func basicInfoFromPayload(payload interface{}) rspace.BasicInfo {
 	m, ok := payload.(map[string]interface{})
 	if !ok {
-		exitWithStdErrMsg(fmt.Sprintf("want type map[string]interface{};  got %T", payload))
+		exitWithErr(errors.New(fmt.Sprintf("want type map[string]interface{};  got %T", payload)))
 	}
 	rc := rspace.IdentifiableNamable{}
 	if id, ok := m["id"]; ok {
@@ -52,14 +52,32 @@ rspace eln listDocuments --or --name experiment* --createdAfter 2020-01-01
 //  list the first hundred documents created
 rspace eln listDocuments --orderBy created --sortOrder asc --maxResults 100
 
+//  list the next hundred
+rspace eln listDocuments --orderBy created --sortOrder asc --maxResults 100 --page 1
+
+// list all documents tagged 'pcr', however many there are
+rspace eln listDocuments --tag pcr --all
+
+// choose which fields to show
+rspace eln listDocuments --columns globalId,name,tags,owner.username
+
+// or format each document with a Go template
+rspace eln listDocuments -f template --template '{{.GlobalId}} {{.Name}}'
+
+// search for 'pcr', then keep the ids of documents tagged exactly 'pcr'
+rspace eln listDocuments --query pcr --jmespath "Documents[?Tags == 'pcr'].GlobalId"
+
 // list documents created from a particular form:
 rspace eln listDocuments --form FM12345
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContextWithTimeout(30)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContextWithTimeout(30)
+		if err != nil {
+			return err
+		}
 		cfg := configurePagination()
-		doListDocs(context, cfg)
+		return doListDocs(context, cfg)
 	},
 }
 
@@ -71,6 +89,20 @@ func (ds *DocListFormatter) ToJson() string {
 	return prettyMarshal(ds.DocumentList)
 }
 
+func (ds *DocListFormatter) appendPage(next StreamingFormatter) {
+	page := next.(*DocListFormatter).DocumentList
+	ds.Documents = append(ds.Documents, page.Documents...)
+	ds.TotalHits = page.TotalHits
+}
+
+func (ds *DocListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, doc := range ds.Documents {
+		rc = append(rc, doc)
+	}
+	return rc
+}
+
 func (ds *DocListFormatter) ToQuiet() []identifiable {
 	rows := make([]identifiable, 0)
 	for _, res := range ds.DocumentList.Documents {
@@ -96,22 +128,32 @@ func (ds *DocListFormatter) ToTable() *TableResult {
 	return &TableResult{headers, rows}
 }
 
-func doListDocs(ctx *Context, cfg rspace.RecordListingConfig) {
-	var docList *rspace.DocumentList
-	var err error
+func doListDocs(ctx *Context, cfg rspace.RecordListingConfig) error {
+	stream := ctx.newResultStream()
+	err := newPageIterator(ctx, cfg).forEachPage(func(pageCfg rspace.RecordListingConfig) (int, int, error) {
+		docList, err := listDocsPage(ctx, pageCfg)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := stream.writePage(&DocListFormatter{docList}); err != nil {
+			return 0, 0, err
+		}
+		return docList.TotalHits, len(docList.Documents), nil
+	})
+	if err != nil {
+		return err
+	}
+	return stream.close()
+}
+
+func listDocsPage(ctx *Context, cfg rspace.RecordListingConfig) (*rspace.DocumentList, error) {
 	if len(searchQuery) > 0 {
-		docList, err = ctx.WebClient.SearchDocuments(cfg, searchQuery)
+		return ctx.WebClient.SearchDocuments(cfg, searchQuery)
 	} else if advancedSrchArgsAreProvided() {
-		docList, err = doAdvancedSearch(ctx, cfg)
+		return doAdvancedSearch(ctx, cfg)
 	} else {
-		docList, err = ctx.WebClient.Documents(cfg)
-	}
-
-	if err != nil {
-		exitWithErr(err)
+		return ctx.WebClient.Documents(cfg)
 	}
-	formatter := DocListFormatter{docList}
-	ctx.writeResult(&formatter)
 }
 func advancedSrchArgsAreProvided() bool {
 	var advSearchArgs = []string{nameSearchArg, tagSrchArg, createdBeforeSrchArg, createdAfterSrcArg,
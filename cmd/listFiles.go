@@ -34,29 +34,39 @@ var listFilesCmd = &cobra.Command{
 // list document files, usual pagination options are available	 
 rspace eln listFiles --mediaType document --orderBy name --sortOrder asc --maxResults 100 
 
-// images
-rspace eln listFiles --mediaType image
+// all images
+rspace eln listFiles --mediaType image --all
 
 // Media (Audio/Video)
 rspace eln listFiles --mediaType av
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContext()
+		if err != nil {
+			return err
+		}
 		cfg := configurePagination()
-		doListFiles(context, cfg)
+		return doListFiles(context, cfg)
 	},
 }
 
-func doListFiles(ctx *Context, cfg rspace.RecordListingConfig) {
-	var filesList *rspace.FileList
-	var err error
-	filesList, err = ctx.WebClient.Files(cfg, mediaTypeArg)
+func doListFiles(ctx *Context, cfg rspace.RecordListingConfig) error {
+	stream := ctx.newResultStream()
+	err := newPageIterator(ctx, cfg).forEachPage(func(pageCfg rspace.RecordListingConfig) (int, int, error) {
+		filesList, err := ctx.WebClient.Files(pageCfg, mediaTypeArg)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := stream.writePage(&FileListFormatter{FileArrayList{processResults(filesList)}}); err != nil {
+			return 0, 0, err
+		}
+		return filesList.TotalHits, len(filesList.Files), nil
+	})
 	if err != nil {
-		exitWithErr(err)
+		return err
 	}
-	formatter := &FileListFormatter{FileArrayList{processResults(filesList)}}
-	ctx.writeResult(formatter)
+	return stream.close()
 }
 
 type FileArrayList struct {
@@ -70,6 +80,18 @@ func (fs *FileListFormatter) ToJson() string {
 	return prettyMarshal(fs.FileArrayList.fList)
 }
 
+func (fs *FileListFormatter) appendPage(next StreamingFormatter) {
+	fs.fList = append(fs.fList, next.(*FileListFormatter).fList...)
+}
+
+func (fs *FileListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, file := range fs.fList {
+		rc = append(rc, file)
+	}
+	return rc
+}
+
 func (ds *FileListFormatter) ToQuiet() []identifiable {
 	return toIdentifiableFile(ds.FileArrayList.fList)
 }
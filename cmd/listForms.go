@@ -29,24 +29,37 @@ var listFormsCmd = &cobra.Command{
 	Long:  `List forms, sorted or paginated`,
 	Example: `
 rspace eln listForms --orderBy name --maxResults 100
+
+// all forms
+rspace eln listForms --all
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContext()
+		if err != nil {
+			return err
+		}
 		cfg := configurePagination()
-		doListForms(context, cfg)
+		return doListForms(context, cfg)
 	},
 }
 
-func doListForms(ctx *Context, cfg rspace.RecordListingConfig) {
-	var formsList *rspace.FormList
-	var err error
-	formsList, err = ctx.WebClient.Forms(cfg)
+func doListForms(ctx *Context, cfg rspace.RecordListingConfig) error {
+	stream := ctx.newResultStream()
+	err := newPageIterator(ctx, cfg).forEachPage(func(pageCfg rspace.RecordListingConfig) (int, int, error) {
+		formsList, err := ctx.WebClient.Forms(pageCfg)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := stream.writePage(&FormListFormatter{formsList}); err != nil {
+			return 0, 0, err
+		}
+		return formsList.TotalHits, len(formsList.Forms), nil
+	})
 	if err != nil {
-		exitWithErr(err)
+		return err
 	}
-	formatter := &FormListFormatter{formsList}
-	ctx.writeResult(formatter)
+	return stream.close()
 }
 
 type FormListFormatter struct {
@@ -57,6 +70,20 @@ func (fs *FormListFormatter) ToJson() string {
 	return prettyMarshal(fs.FormList)
 }
 
+func (fs *FormListFormatter) appendPage(next StreamingFormatter) {
+	page := next.(*FormListFormatter).FormList
+	fs.Forms = append(fs.Forms, page.Forms...)
+	fs.TotalHits = page.TotalHits
+}
+
+func (fs *FormListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, form := range fs.Forms {
+		rc = append(rc, form)
+	}
+	return rc
+}
+
 func (ds *FormListFormatter) ToQuiet() []identifiable {
 	rows := make([]identifiable, 0)
 	for _, res := range ds.FormList.Forms {
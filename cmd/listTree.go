@@ -38,26 +38,42 @@ rspace eln listTree --filter folder
 
 // show notebooks in specified folde
 rspace eln listTree --filter notebook --folder 1234
+
+// show everything in a large folder
+rspace eln listTree --folder 1234 --all
 `,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContext()
+		if err != nil {
+			return err
+		}
 		cfg := configurePagination()
-		doListTree(context, cfg)
+		return doListTree(context, cfg)
 	},
 }
 
-func doListTree(ctx *Context, cfg rspace.RecordListingConfig) {
+func doListTree(ctx *Context, cfg rspace.RecordListingConfig) error {
 	var filters = make([]string, 0)
 	if len(treeFilterArg) > 0 {
 		filters = strings.Split(treeFilterArg, ",")
 	}
 	folderId, _ := idFromGlobalId(folderIdArg)
-	folderList, err := ctx.WebClient.FolderTree(cfg, folderId, filters)
+	stream := ctx.newResultStream()
+	err := newPageIterator(ctx, cfg).forEachPage(func(pageCfg rspace.RecordListingConfig) (int, int, error) {
+		folderList, err := ctx.WebClient.FolderTree(pageCfg, folderId, filters)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := stream.writePage(&FolderListFormatter{folderList}); err != nil {
+			return 0, 0, err
+		}
+		return folderList.TotalHits, len(folderList.Records), nil
+	})
 	if err != nil {
-		exitWithErr(err)
+		return err
 	}
-	ctx.writeResult(&FolderListFormatter{folderList})
+	return stream.close()
 }
 
 type FolderListFormatter struct {
@@ -68,6 +84,20 @@ func (ds *FolderListFormatter) ToJson() string {
 	return prettyMarshal(ds.FolderList)
 }
 
+func (ds *FolderListFormatter) appendPage(next StreamingFormatter) {
+	page := next.(*FolderListFormatter).FolderList
+	ds.Records = append(ds.Records, page.Records...)
+	ds.TotalHits = page.TotalHits
+}
+
+func (ds *FolderListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, record := range ds.Records {
+		rc = append(rc, record)
+	}
+	return rc
+}
+
 func (ds *FolderListFormatter) ToQuiet() []identifiable {
 	return toIdentifiable(ds.FolderList)
 }
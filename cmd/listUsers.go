@@ -30,39 +30,49 @@ var listUsersCmd = &cobra.Command{
 	Short: "Lists users - requires sysadmin role!",
 	Long: `List users, sorted or paginated.
 
-Please note that currently users are ordered by account creation date only (default is most recent first),
-but there is no limit on the page size, so you can retrieve all users in one query, then
-filter or sort using standard Unix utilities.
+Please note that currently users are ordered by account creation date only (default is most recent first).
+Use --all to retrieve all users, then filter or sort using standard Unix utilities.
+The total number of users is reported after the results are retrieved.
 
 	`,
 	Example: `
 // find out how many users you have:
-rspace eln listUsers --maxResults 20 -f json | jq '.TotalHits'
+rspace eln listUsers --maxResults 1 --jmespath TotalHits
 
-// or just use 'grep' if you don't have 'jq' installed:
-rspace eln listUsers --maxResults 20 -f json | grep TotalHits
+// usernames of users whose email is at a particular domain
+rspace eln listUsers --all --jmespath "Users[?ends_with(Email, '@example.com')].Username"
 
-// now you can get all users in one go and sort by any column e.g. by username:
-rspace eln listUsers --maxResults 120 | sort -k2
+// get all users, 100 at a time, and sort by any column e.g. by username:
+rspace eln listUsers --all --maxResults 100 | sort -k2
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		context, err := initialiseContext()
+		if err != nil {
+			return err
+		}
 		cfg := configurePagination()
 		cfg.OrderBy = "creationDate"
-		doListusers(context, cfg)
+		return doListusers(context, cfg)
 	},
 }
 
-func doListusers(ctx *Context, cfg rspace.RecordListingConfig) {
-	var usersList *rspace.UserList
-	var err error
-	usersList, err = ctx.WebClient.Users(time.Time{}, time.Time{}, cfg)
+func doListusers(ctx *Context, cfg rspace.RecordListingConfig) error {
+	stream := ctx.newResultStream()
+	err := newPageIterator(ctx, cfg).forEachPage(func(pageCfg rspace.RecordListingConfig) (int, int, error) {
+		usersList, err := ctx.WebClient.Users(time.Time{}, time.Time{}, pageCfg)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := stream.writePage(&UserListFormatter{usersList}); err != nil {
+			return 0, 0, err
+		}
+		return usersList.TotalHits, len(usersList.Users), nil
+	})
 	if err != nil {
-		exitWithErr(err)
+		return err
 	}
-	formatter := &UserListFormatter{usersList}
-	ctx.writeResult(formatter)
+	return stream.close()
 }
 
 type UserListFormatter struct {
@@ -73,6 +83,20 @@ func (fs *UserListFormatter) ToJson() string {
 	return prettyMarshal(fs.UserList)
 }
 
+func (fs *UserListFormatter) appendPage(next StreamingFormatter) {
+	page := next.(*UserListFormatter).UserList
+	fs.Users = append(fs.Users, page.Users...)
+	fs.TotalHits = page.TotalHits
+}
+
+func (fs *UserListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, user := range fs.Users {
+		rc = append(rc, user)
+	}
+	return rc
+}
+
 func (ds *UserListFormatter) ToQuiet() []identifiable {
 	rows := make([]identifiable, 0)
 	for _, res := range ds.UserList.Users {
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	mdhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// Markdown is rendered with goldmark (CommonMark, plus GitHub tables and strikethrough). HTML in
+// the Markdown is escaped rather than passed through, and links with unsafe URLs, e.g. javascript:,
+// are dropped, so the rendered HTML is safe to put in a document.
+
+// markdownImageLinker gives the HTML for an image, e.g. after uploading it to the Gallery
+type markdownImageLinker func(src, alt string) (string, error)
+
+// renderMarkdown converts Markdown to HTML. If images is nil, images are linked by their URL.
+func renderMarkdown(source string, images markdownImageLinker) (string, error) {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.Table, extension.Strikethrough),
+		goldmark.WithRendererOptions(
+			mdhtml.WithXHTML(),
+			renderer.WithNodeRenderers(util.Prioritized(&markdownNodeRenderer{images}, 100)),
+		),
+	)
+	out := &bytes.Buffer{}
+	if err := md.Convert([]byte(source), out); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// markdownNodeRenderer renders images through a markdownImageLinker, and HTML as text
+type markdownNodeRenderer struct {
+	images markdownImageLinker
+}
+
+func (r *markdownNodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindImage, r.renderImage)
+	reg.Register(ast.KindRawHTML, r.renderRawHtml)
+	reg.Register(ast.KindHTMLBlock, r.renderHtmlBlock)
+}
+
+func (r *markdownNodeRenderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.Image)
+	src, alt := string(n.Destination), markdownPlainText(n, source)
+	rendered := markdownImageTag(src, alt)
+	if r.images != nil {
+		var err error
+		if rendered, err = r.images(src, alt); err != nil {
+			return ast.WalkStop, err
+		}
+	}
+	_, _ = w.WriteString(rendered)
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *markdownNodeRenderer) renderRawHtml(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		segments := node.(*ast.RawHTML).Segments
+		for i := 0; i < segments.Len(); i++ {
+			segment := segments.At(i)
+			_, _ = w.WriteString(html.EscapeString(string(segment.Value(source))))
+		}
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// HTML blocks are shown as paragraphs of text
+func (r *markdownNodeRenderer) renderHtmlBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.HTMLBlock)
+	text := &strings.Builder{}
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		text.Write(line.Value(source))
+	}
+	if n.HasClosure() {
+		text.Write(n.ClosureLine.Value(source))
+	}
+	fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(strings.TrimRight(text.String(), "\n")))
+	return ast.WalkSkipChildren, nil
+}
+
+func markdownImageTag(src, alt string) string {
+	if mdhtml.IsDangerousURL([]byte(strings.ToLower(src))) {
+		src = ""
+	}
+	return fmt.Sprintf(`<img src="%s" alt="%s" />`, html.EscapeString(src), html.EscapeString(alt))
+}
+
+// markdownPlainText is the text of a node without formatting, e.g. for the alternative text of an image
+func markdownPlainText(node ast.Node, source []byte) string {
+	text := &strings.Builder{}
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			switch t := n.(type) {
+			case *ast.Text:
+				text.Write(t.Segment.Value(source))
+			case *ast.String:
+				text.Write(t.Value)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return text.String()
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	cases := []struct{ markdown, html string }{
+		{"# Results ##", "<h1>Results</h1>\n"},
+		{"#hashtag", "<p>#hashtag</p>\n"},
+		{"Some **bold**, *em* and ~~old~~ text\nwith `a <b>` and snake_case_name", "<p>Some <strong>bold</strong>, <em>em</em> and <del>old</del> text\nwith <code>a &lt;b&gt;</code> and snake_case_name</p>\n"},
+		{"line 1  \nline 2", "<p>line 1<br />\nline 2</p>\n"},
+		{"<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"a <b>bold</b> word", "<p>a &lt;b&gt;bold&lt;/b&gt; word</p>\n"},
+		{"\\*not em\\*", "<p>*not em*</p>\n"},
+		{"[RSpace](https://researchspace.com \"home\") [bad](javascript:alert(1)) <https://a.b/c>",
+			"<p><a href=\"https://researchspace.com\" title=\"home\">RSpace</a> <a href=\"\">bad</a> <a href=\"https://a.b/c\">https://a.b/c</a></p>\n"},
+		{"```go\nif a < b {\n}\n```", "<pre><code class=\"language-go\">if a &lt; b {\n}\n</code></pre>\n"},
+		{"    indented\n    code", "<pre><code>indented\ncode\n</code></pre>\n"},
+		{"> quoted\n> **text**", "<blockquote>\n<p>quoted\n<strong>text</strong></p>\n</blockquote>\n"},
+		{"---", "<hr />\n"},
+		{"- a\n- b\n  - c\n- d", "<ul>\n<li>a</li>\n<li>b\n<ul>\n<li>c</li>\n</ul>\n</li>\n<li>d</li>\n</ul>\n"},
+		{"3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n"},
+		{"- a\n\n- b", "<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>\n"},
+		{"| Sample | Amount |\n|---|--:|\n| s1 | 1 \\| 2 |", "<table>\n<thead>\n<tr>\n<th>Sample</th>\n<th align=\"right\">Amount</th>\n</tr>\n</thead>\n" +
+			"<tbody>\n<tr>\n<td>s1</td>\n<td align=\"right\">1 | 2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"![a *gel*](gel.png)", "<p><img src=\"gel.png\" alt=\"a gel\" /></p>\n"},
+	}
+	for _, c := range cases {
+		html, err := renderMarkdown(c.markdown, nil)
+		if err != nil || html != c.html {
+			t.Errorf("Expected %q to render as\n%q but was\n%q, %v", c.markdown, c.html, html, err)
+		}
+	}
+}
+
+func TestMarkdownImageErrorsAreReturned(t *testing.T) {
+	_, err := renderMarkdown("![x](missing.png)", func(src, alt string) (string, error) {
+		return "", errors.New("no " + src)
+	})
+	if err == nil || err.Error() != "no missing.png" {
+		t.Errorf("Expected image error but got %v", err)
+	}
+}
+
+func TestAddDocumentFromMarkdownUploadsLocalImages(t *testing.T) {
+	ctx, client, _, errOut := newMemoryContext("quiet")
+	dir, _ := ioutil.TempDir("", "markdown")
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "images"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "images", "gel.png"), []byte("png"), 0644)
+	notes := "# Gel\n![gel](images/gel.png)\n\nAgain: ![same gel](images/gel.png) ![logo](https://example.com/logo.png)\n"
+	ioutil.WriteFile(filepath.Join(dir, "notes.md"), []byte(notes), 0644)
+
+	err := doAddDocRun(addDocArgs{NameArg: "gel", ContentFile: filepath.Join(dir, "notes.md")}, ctx, client)
+	if err != nil {
+		t.Fatalf("Expected document to be created but got %v", err)
+	}
+	gallery := client.files[len(client.files)-1].info
+	if gallery.Name != "gel.png" || gallery.Caption != "gel" {
+		t.Errorf("Expected image to be uploaded but last file was %+v", gallery)
+	}
+	fileLink := "<fileId=" + strconv.Itoa(gallery.Id) + ">"
+	expected := "<h1>Gel</h1>\n<p>" + fileLink + "</p>\n<p>Again: " + fileLink +
+		" <img src=\"https://example.com/logo.png\" alt=\"logo\" /></p>\n"
+	assertEqualString(t, expected, client.documents[0].Fields[0].Content)
+	if strings.Count(errOut.String(), "Uploaded image") != 1 {
+		t.Errorf("Expected image to be uploaded once but got %s", errOut.String())
+	}
+
+	// images that don't exist are reported, and no document is created
+	err = doAddDocRun(addDocArgs{NameArg: "missing", Content: "![x](nothere.png)", ContentFormat: "markdown"}, ctx, client)
+	if exitCodeFor(err) != EXIT_VALIDATION || len(client.documents) != 1 {
+		t.Errorf("Expected a missing image to be rejected but got %v", err)
+	}
+	err = doAddDocRun(addDocArgs{Content: "x", ContentFormat: "rtf"}, ctx, client)
+	if exitCodeFor(err) != EXIT_VALIDATION {
+		t.Errorf("Expected an unknown content format to be rejected but got %v", err)
+	}
+}
+
+func TestMarkdownImagesAreUploadedThroughInjectedClient(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	ctx.WebClient = nil
+	dir, _ := ioutil.TempDir("", "markdown")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "gel.png"), []byte("png"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "notes.md"), []byte("![gel](gel.png)\n"), 0644)
+
+	err := doAddDocRun(addDocArgs{NameArg: "gel", ContentFile: filepath.Join(dir, "notes.md")}, ctx, client)
+	if err != nil {
+		t.Fatalf("Expected document to be created but got %v", err)
+	}
+	if len(client.files) == 0 || client.files[len(client.files)-1].info.Name != "gel.png" {
+		t.Fatalf("Expected gel.png to be uploaded to the injected client")
+	}
+	fileLink := "<fileId=" + strconv.Itoa(client.files[len(client.files)-1].info.Id) + ">"
+	if !strings.Contains(client.documents[0].Fields[0].Content, fileLink) {
+		t.Errorf("Expected content to link to %s but was %s", fileLink, client.documents[0].Fields[0].Content)
+	}
+}
@@ -0,0 +1,900 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+// memoryRSpace is an RSpace held in memory, with documents, folders, Gallery files, forms, groups,
+// users, activity and export jobs. It implements RSpaceClient, so that commands can be run offline.
+// Export jobs progress each time they are polled.
+type memoryRSpace struct {
+	mutex        sync.Mutex
+	lastId       int
+	username     string
+	homeFolderId int
+	basicFormId  int
+	// base of export download links
+	baseUrl    string
+	documents  []*rspace.Document
+	folders    []*rspace.Folder
+	files      []*memoryFile
+	forms      []*rspace.Form
+	groups     []*rspace.GroupInfo
+	users      []*rspace.UserInfo
+	activities []rspace.Activity
+	jobs       []*memoryJob
+	// clock for timestamps, which can be fixed in tests
+	now func() time.Time
+}
+
+type memoryFile struct {
+	info    *rspace.FileInfo
+	content []byte
+}
+
+type memoryJob struct {
+	job     *rspace.Job
+	archive []byte
+}
+
+// progress of an export job each time it is polled
+const MEMORY_JOB_PROGRESS_STEP = 50
+
+const memoryTimestampLayout = "2006-01-02T15:04:05.000Z"
+
+// newMemoryRSpace creates an RSpace with a user, their home folder and the Basic Document form
+func newMemoryRSpace() *memoryRSpace {
+	m := &memoryRSpace{username: "user1", baseUrl: "http://localhost:8080/api/v1", now: time.Now}
+	m.addUser("user1", "user1@example.com")
+	m.homeFolderId = m.addFolder("Home", false, 0).Id
+	basic := m.addForm("Basic Document", []rspace.FormField{memoryFormField("Data", "Text")})
+	basic.FormState = "PUBLISHED"
+	m.basicFormId = basic.Id
+	return m
+}
+
+func memoryFormField(name, fieldType string) rspace.FormField {
+	return rspace.FormField{IdentifiableNamable: &rspace.IdentifiableNamable{Name: name}, Type: fieldType}
+}
+
+func (m *memoryRSpace) nextId(prefix string) *rspace.IdentifiableNamable {
+	m.lastId++
+	return &rspace.IdentifiableNamable{Id: m.lastId, GlobalId: fmt.Sprintf("%s%d", prefix, m.lastId)}
+}
+
+func (m *memoryRSpace) timestamp() string {
+	return m.now().UTC().Format(memoryTimestampLayout)
+}
+
+func (m *memoryRSpace) recordActivity(action string, item *rspace.IdentifiableNamable) {
+	payload := map[string]interface{}{"id": item.GlobalId, "name": item.Name}
+	m.activities = append(m.activities, rspace.Activity{Action: action, Timestamp: m.timestamp(),
+		Username: m.username, Payload: payload})
+}
+
+func memoryNotFound(kind string, id int) error {
+	return newCliError(EXIT_NOT_FOUND, fmt.Sprintf("%s with id %d not found", kind, id))
+}
+
+// pageBounds is the range of items in the page requested by cfg
+func pageBounds(total int, cfg rspace.RecordListingConfig) (int, int) {
+	pageSize := cfg.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	from := cfg.PageNumber * pageSize
+	if from > total {
+		from = total
+	}
+	to := from + pageSize
+	if to > total {
+		to = total
+	}
+	return from, to
+}
+
+// jsonFields reads any struct by its JSON field names, in lower case. API posts
+// whose fields aren't exported are read this way.
+func jsonFields(v interface{}) map[string]interface{} {
+	rc := make(map[string]interface{})
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return rc
+	}
+	fields := make(map[string]interface{})
+	json.Unmarshal(bytes, &fields)
+	for key, value := range fields {
+		rc[strings.ToLower(key)] = value
+	}
+	return rc
+}
+
+func stringField(fields map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		if s, ok := fields[name].(string); ok && len(s) > 0 {
+			return s
+		}
+	}
+	return ""
+}
+
+// users, folders and forms
+
+func (m *memoryRSpace) addUser(username, email string) *rspace.UserInfo {
+	user := &rspace.UserInfo{Id: m.nextId("US").Id, Username: username, Email: email}
+	m.users = append(m.users, user)
+	return user
+}
+
+func (m *memoryRSpace) userByName(username string) *rspace.UserInfo {
+	for _, user := range m.users {
+		if user.Username == username {
+			return user
+		}
+	}
+	return nil
+}
+
+func (m *memoryRSpace) addFolder(name string, isNotebook bool, parentId int) *rspace.Folder {
+	prefix := "FL"
+	if isNotebook {
+		prefix = "NB"
+	}
+	namable := m.nextId(prefix)
+	namable.Name = name
+	folder := &rspace.Folder{IdentifiableNamable: namable, Created: m.timestamp(), LastModified: m.timestamp(),
+		ParentFolderId: parentId, Notebook: isNotebook}
+	m.folders = append(m.folders, folder)
+	m.recordActivity("CREATE", namable)
+	return folder
+}
+
+func (m *memoryRSpace) folderById(id int) *rspace.Folder {
+	for _, folder := range m.folders {
+		if folder.Id == id {
+			return folder
+		}
+	}
+	return nil
+}
+
+func (m *memoryRSpace) addForm(name string, fields []rspace.FormField) *rspace.Form {
+	namable := m.nextId("FM")
+	namable.Name = name
+	for i := range fields {
+		fieldId := m.nextId("FF")
+		fieldId.Name = fields[i].Name
+		fields[i].IdentifiableNamable = fieldId
+	}
+	form := &rspace.Form{IdentifiableNamable: namable, StableId: fmt.Sprintf("SF%d", namable.Id), FormState: "NEW", Fields: fields}
+	m.forms = append(m.forms, form)
+	return form
+}
+
+func (m *memoryRSpace) formById(id int) *rspace.Form {
+	for _, form := range m.forms {
+		if form.Id == id {
+			return form
+		}
+	}
+	return nil
+}
+
+// addGroup creates a group of existing users, the first being the PI
+func (m *memoryRSpace) addGroup(name string, usernames ...string) (*rspace.GroupInfo, error) {
+	group := &rspace.GroupInfo{Id: m.nextId("GP").Id, Name: name, Type: "LAB_GROUP"}
+	for i, username := range usernames {
+		user := m.userByName(username)
+		if user == nil {
+			return nil, newCliError(EXIT_NOT_FOUND, fmt.Sprintf("User '%s' not found", username))
+		}
+		role := "DEFAULT"
+		if i == 0 {
+			role = "PI"
+		}
+		group.Members = append(group.Members, rspace.UserGroupInfo{Id: user.Id, Username: username, Role: role})
+	}
+	group.SharedFolderId = m.addFolder(name+"_SHARED", false, 0).Id
+	m.groups = append(m.groups, group)
+	return group, nil
+}
+
+func (m *memoryRSpace) Status() (*rspace.Status, error) {
+	return &rspace.Status{Message: "OK", RSpaceVersion: "in-memory"}, nil
+}
+
+// documents
+
+func (m *memoryRSpace) NewBasicDocumentWithContent(name, tags, content string) (*rspace.Document, error) {
+	return m.NewDocumentWithContent(&rspace.DocumentPost{Name: name, Tags: tags,
+		Fields: []rspace.FieldContent{rspace.FieldContent{Content: content}}})
+}
+
+func (m *memoryRSpace) NewDocumentWithContent(post *rspace.DocumentPost) (*rspace.Document, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	formId := post.FormID.Id
+	if formId == 0 {
+		formId = m.basicFormId
+	}
+	form := m.formById(formId)
+	if form == nil {
+		return nil, memoryNotFound("Form", formId)
+	}
+	if len(post.Fields) > len(form.Fields) {
+		return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("Form '%s' has %d fields, but content for %d was supplied",
+			form.Name, len(form.Fields), len(post.Fields)))
+	}
+	parentId := post.ParentFolderId
+	if parentId == 0 {
+		parentId = m.homeFolderId
+	}
+	if m.folderById(parentId) == nil {
+		return nil, memoryNotFound("Folder", parentId)
+	}
+	namable := m.nextId("SD")
+	namable.Name = post.Name
+	if len(namable.Name) == 0 {
+		namable.Name = "Untitled document"
+	}
+	owner := m.userByName(m.username)
+	info := &rspace.DocumentInfo{IdentifiableNamable: namable, Created: m.timestamp(), LastModified: m.timestamp(),
+		ParentFolderId: parentId, Tags: post.Tags, UserInfo: *owner,
+		Form: rspace.FormInfo{Id: form.Id, GlobalId: form.GlobalId, Name: form.Name, StableId: form.StableId}}
+	doc := &rspace.Document{DocumentInfo: info}
+	for i, formField := range form.Fields {
+		fieldId := m.nextId("FD")
+		field := rspace.Field{Id: fieldId.Id, GlobalId: fieldId.GlobalId, Name: formField.Name, Type: formField.Type,
+			LastModified: m.timestamp()}
+		if i < len(post.Fields) {
+			field.Content = post.Fields[i].Content
+		}
+		doc.Fields = append(doc.Fields, field)
+	}
+	m.documents = append(m.documents, doc)
+	m.recordActivity("CREATE", namable)
+	return copyDocument(doc), nil
+}
+
+func (m *memoryRSpace) documentById(id int) (int, *rspace.Document) {
+	for i, doc := range m.documents {
+		if doc.Id == id {
+			return i, doc
+		}
+	}
+	return -1, nil
+}
+
+// copyDocument is a copy of a stored document, so that callers see changes only when they
+// read the document again, as with a real RSpace
+func copyDocument(doc *rspace.Document) *rspace.Document {
+	namable := *doc.IdentifiableNamable
+	info := *doc.DocumentInfo
+	info.IdentifiableNamable = &namable
+	fields := make([]rspace.Field, len(doc.Fields))
+	copy(fields, doc.Fields)
+	return &rspace.Document{DocumentInfo: &info, Fields: fields}
+}
+
+func (m *memoryRSpace) DocumentById(id int) (*rspace.Document, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, doc := m.documentById(id); doc != nil {
+		return copyDocument(doc), nil
+	}
+	return nil, memoryNotFound("Document", id)
+}
+
+// DocumentEdit updates the name, tags and fields that are set in post. Fields are identified
+// by their id, or if that's not set, by their position.
+func (m *memoryRSpace) DocumentEdit(id int, post *rspace.DocumentPost) (*rspace.Document, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, doc := m.documentById(id)
+	if doc == nil {
+		return nil, memoryNotFound("Document", id)
+	}
+	if len(post.Name) > 0 {
+		doc.Name = post.Name
+	}
+	if len(post.Tags) > 0 {
+		doc.Tags = post.Tags
+	}
+	for i, content := range post.Fields {
+		index := i
+		if content.Id != 0 {
+			index = -1
+			for j, field := range doc.Fields {
+				if field.Id == content.Id {
+					index = j
+				}
+			}
+		}
+		if index < 0 || index >= len(doc.Fields) {
+			return nil, memoryNotFound("Field", content.Id)
+		}
+		doc.Fields[index].Content = content.Content
+		doc.Fields[index].LastModified = m.timestamp()
+	}
+	doc.LastModified = m.timestamp()
+	m.recordActivity("WRITE", doc.IdentifiableNamable)
+	return copyDocument(doc), nil
+}
+
+func (m *memoryRSpace) DeleteDocument(id int) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	i, doc := m.documentById(id)
+	if doc == nil {
+		return false, memoryNotFound("Document", id)
+	}
+	m.documents = append(m.documents[:i], m.documents[i+1:]...)
+	m.recordActivity("DELETE", doc.IdentifiableNamable)
+	return true, nil
+}
+
+func (m *memoryRSpace) Documents(cfg rspace.RecordListingConfig) (*rspace.DocumentList, error) {
+	return m.SearchDocuments(cfg, "")
+}
+
+// SearchDocuments matches the query against document names, tags and global ids
+func (m *memoryRSpace) SearchDocuments(cfg rspace.RecordListingConfig, query string) (*rspace.DocumentList, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	query = strings.ToLower(query)
+	matches := make([]rspace.DocumentInfo, 0)
+	for _, doc := range m.documents {
+		if strings.Contains(strings.ToLower(doc.Name), query) || strings.Contains(strings.ToLower(doc.Tags), query) ||
+			strings.ToLower(doc.GlobalId) == query {
+			matches = append(matches, *doc.DocumentInfo)
+		}
+	}
+	sortDocumentInfos(matches, cfg)
+	from, to := pageBounds(len(matches), cfg)
+	return &rspace.DocumentList{Documents: matches[from:to], TotalHits: len(matches), PageNumber: cfg.PageNumber}, nil
+}
+
+func sortDocumentInfos(docs []rspace.DocumentInfo, cfg rspace.RecordListingConfig) {
+	key := func(doc rspace.DocumentInfo) string {
+		switch cfg.OrderBy {
+		case "name":
+			return strings.ToLower(doc.Name)
+		case "created":
+			return doc.Created
+		}
+		return doc.LastModified
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		if cfg.SortOrder == "asc" {
+			return key(docs[i]) < key(docs[j])
+		}
+		return key(docs[i]) > key(docs[j])
+	})
+}
+
+func (m *memoryRSpace) AdvancedSearchDocuments(cfg rspace.RecordListingConfig, query *rspace.SearchQuery) (*rspace.DocumentList, error) {
+	return nil, errors.New("Advanced search is not supported by the in-memory RSpace")
+}
+
+// ImportWord creates a document named after the file
+func (m *memoryRSpace) ImportWord(filePath string, folderId, imageFolderId int) (*rspace.DocumentInfo, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, err
+	}
+	return m.importDocument(filepath.Base(filePath), folderId)
+}
+
+func (m *memoryRSpace) importDocument(fileName string, folderId int) (*rspace.DocumentInfo, error) {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	doc, err := m.NewDocumentWithContent(&rspace.DocumentPost{Name: name, ParentFolderId: folderId,
+		Fields: []rspace.FieldContent{rspace.FieldContent{Content: "<p>Imported from " + html.EscapeString(fileName) + "</p>"}}})
+	if err != nil {
+		return nil, err
+	}
+	return doc.DocumentInfo, nil
+}
+
+// folders and notebooks
+
+func (m *memoryRSpace) FolderNew(post *rspace.FolderPost) (*rspace.Folder, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	parentId := post.ParentFolderId
+	if parentId == 0 {
+		parentId = m.homeFolderId
+	}
+	parent := m.folderById(parentId)
+	if parent == nil {
+		return nil, memoryNotFound("Folder", parentId)
+	}
+	if parent.Notebook {
+		return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("%s is a notebook, which can't contain folders", parent.GlobalId))
+	}
+	name := post.Name
+	if len(name) == 0 {
+		name = "Untitled folder"
+	}
+	return m.addFolder(name, post.IsNotebook, parentId), nil
+}
+
+func (m *memoryRSpace) FolderById(id int) (*rspace.Folder, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if folder := m.folderById(id); folder != nil {
+		return folder, nil
+	}
+	return nil, memoryNotFound("Folder", id)
+}
+
+// FolderTree lists a folder's contents, which are of type 'FOLDER', 'NOTEBOOK' or 'DOCUMENT'
+func (m *memoryRSpace) FolderTree(cfg rspace.RecordListingConfig, folderId int, typesToInclude []string) (*rspace.FolderList, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if folderId == 0 {
+		folderId = m.homeFolderId
+	}
+	if m.folderById(folderId) == nil {
+		return nil, memoryNotFound("Folder", folderId)
+	}
+	include := func(recordType string) bool {
+		if len(typesToInclude) == 0 {
+			return true
+		}
+		return validateArrayContains(typesToInclude, []string{strings.ToLower(recordType)})
+	}
+	records := make([]rspace.RecordInfo, 0)
+	for _, folder := range m.folders {
+		recordType := "FOLDER"
+		if folder.Notebook {
+			recordType = "NOTEBOOK"
+		}
+		if folder.ParentFolderId == folderId && include(recordType) {
+			records = append(records, rspace.RecordInfo{IdentifiableNamable: folder.IdentifiableNamable,
+				Created: folder.Created, LastModified: folder.LastModified, Type: recordType})
+		}
+	}
+	for _, doc := range m.documents {
+		if doc.ParentFolderId == folderId && include("DOCUMENT") {
+			records = append(records, rspace.RecordInfo{IdentifiableNamable: doc.IdentifiableNamable,
+				Created: doc.Created, LastModified: doc.LastModified, Type: "DOCUMENT"})
+		}
+	}
+	from, to := pageBounds(len(records), cfg)
+	return &rspace.FolderList{Records: records[from:to], TotalHits: len(records), PageNumber: cfg.PageNumber}, nil
+}
+
+// Gallery files
+
+// UploadFile reads a file into the Gallery
+func (m *memoryRSpace) UploadFile(config rspace.FileUploadConfig) (*rspace.FileInfo, error) {
+	content, err := ioutil.ReadFile(config.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return m.addFile(filepath.Base(config.FilePath), config.Caption, content), nil
+}
+
+func (m *memoryRSpace) addFile(name, caption string, content []byte) *rspace.FileInfo {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	namable := m.nextId("GL")
+	namable.Name = name
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	info := &rspace.FileInfo{IdentifiableNamable: namable, Created: m.timestamp(), Size: len(content),
+		ContentType: contentType, Caption: caption, Version: 1}
+	m.files = append(m.files, &memoryFile{info, content})
+	m.recordActivity("CREATE", namable)
+	return info
+}
+
+// mediaType is the Gallery section a file is shown in: 'image', 'av' or 'document'
+func mediaType(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return "image"
+	case strings.HasPrefix(contentType, "audio/"), strings.HasPrefix(contentType, "video/"):
+		return "av"
+	}
+	return "document"
+}
+
+func (m *memoryRSpace) Files(cfg rspace.RecordListingConfig, mediaTypeFilter string) (*rspace.FileList, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	files := make([]rspace.FileInfo, 0)
+	for _, file := range m.files {
+		if len(mediaTypeFilter) == 0 || mediaType(file.info.ContentType) == mediaTypeFilter {
+			files = append(files, *file.info)
+		}
+	}
+	from, to := pageBounds(len(files), cfg)
+	return &rspace.FileList{Files: files[from:to], TotalHits: len(files), PageNumber: cfg.PageNumber}, nil
+}
+
+func (m *memoryRSpace) fileById(id int) *memoryFile {
+	for _, file := range m.files {
+		if file.info.Id == id {
+			return file
+		}
+	}
+	return nil
+}
+
+// Download writes a Gallery file to a directory
+func (m *memoryRSpace) Download(id int, dir string) (*rspace.FileInfo, error) {
+	m.mutex.Lock()
+	file := m.fileById(id)
+	m.mutex.Unlock()
+	if file == nil {
+		return nil, memoryNotFound("File", id)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, file.info.Name), file.content, 0644); err != nil {
+		return nil, err
+	}
+	return file.info, nil
+}
+
+// forms
+
+func (m *memoryRSpace) Forms(cfg rspace.RecordListingConfig) (*rspace.FormList, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	forms := make([]rspace.Form, 0)
+	for _, form := range m.forms {
+		forms = append(forms, *form)
+	}
+	from, to := pageBounds(len(forms), cfg)
+	return &rspace.FormList{Forms: forms[from:to], TotalHits: len(forms), PageNumber: cfg.PageNumber}, nil
+}
+
+func (m *memoryRSpace) FormById(id int) (*rspace.Form, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if form := m.formById(id); form != nil {
+		return form, nil
+	}
+	return nil, memoryNotFound("Form", id)
+}
+
+// CreateFormJson creates a form from a definition with a name and a list of fields, each with a name and type
+func (m *memoryRSpace) CreateFormJson(jsonFormDef io.Reader) (*rspace.Form, error) {
+	def := rspace.Form{}
+	if err := json.NewDecoder(jsonFormDef).Decode(&def); err != nil {
+		return nil, newCliError(EXIT_VALIDATION, "Invalid form definition: "+err.Error())
+	}
+	if def.IdentifiableNamable == nil || len(def.Name) == 0 || len(def.Fields) == 0 {
+		return nil, newCliError(EXIT_VALIDATION, "A form definition needs a name and at least one field")
+	}
+	for _, field := range def.Fields {
+		if field.IdentifiableNamable == nil || len(field.Name) == 0 || len(field.Type) == 0 {
+			return nil, newCliError(EXIT_VALIDATION, "Each form field needs a name and a type")
+		}
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.addForm(def.Name, def.Fields), nil
+}
+
+func (m *memoryRSpace) CreateFormYaml(yamlFormDef io.Reader) (*rspace.Form, error) {
+	return nil, newCliError(EXIT_VALIDATION, "YAML form definitions are not supported by the in-memory RSpace, please use JSON")
+}
+
+func (m *memoryRSpace) PublishForm(formId int) (*rspace.Form, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	form := m.formById(formId)
+	if form == nil {
+		return nil, memoryNotFound("Form", formId)
+	}
+	form.FormState = "PUBLISHED"
+	return form, nil
+}
+
+// activity and sharing
+
+// Activities lists all activity, most recent first. The query is not applied.
+func (m *memoryRSpace) Activities(query *rspace.ActivityQuery, cfg rspace.RecordListingConfig) (*rspace.ActivityList, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	activities := make([]rspace.Activity, 0)
+	for i := len(m.activities) - 1; i >= 0; i-- {
+		activities = append(activities, m.activities[i])
+	}
+	from, to := pageBounds(len(activities), cfg)
+	return &rspace.ActivityList{Activities: activities[from:to], TotalHits: len(activities), PageNumber: cfg.PageNumber}, nil
+}
+
+func (m *memoryRSpace) itemName(id int) (string, bool) {
+	if _, doc := m.documentById(id); doc != nil {
+		return doc.Name, true
+	}
+	if folder := m.folderById(id); folder != nil && folder.Notebook {
+		return folder.Name, true
+	}
+	return "", false
+}
+
+func (m *memoryRSpace) groupById(id int) *rspace.GroupInfo {
+	for _, group := range m.groups {
+		if group.Id == id {
+			return group
+		}
+	}
+	return nil
+}
+
+func (m *memoryRSpace) userById(id int) *rspace.UserInfo {
+	for _, user := range m.users {
+		if user.Id == id {
+			return user
+		}
+	}
+	return nil
+}
+
+// Share shares documents and notebooks with existing groups and users
+func (m *memoryRSpace) Share(post *rspace.SharePost) (*rspace.ShareInfoList, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, group := range post.Groups {
+		if m.groupById(group.Id) == nil {
+			return nil, memoryNotFound("Group", group.Id)
+		}
+	}
+	for _, user := range post.Users {
+		if m.userById(user.Id) == nil {
+			return nil, memoryNotFound("User", user.Id)
+		}
+	}
+	results := make([]rspace.ShareResult, 0)
+	for _, itemId := range post.ItemsToShare {
+		name, ok := m.itemName(itemId)
+		if !ok {
+			return nil, memoryNotFound("Document or notebook", itemId)
+		}
+		for _, group := range post.Groups {
+			results = append(results, rspace.ShareResult{Id: m.nextId("SH").Id, ItemId: itemId, ItemName: name,
+				TargetType: "GROUP", Permission: strings.ToUpper(group.Permission)})
+		}
+		for _, user := range post.Users {
+			results = append(results, rspace.ShareResult{Id: m.nextId("SH").Id, ItemId: itemId, ItemName: name,
+				TargetType: "USER", Permission: strings.ToUpper(user.Permission)})
+		}
+	}
+	return &rspace.ShareInfoList{ShareInfos: results}, nil
+}
+
+// export jobs
+
+// Export starts an export job, which completes after being polled a few times
+func (m *memoryRSpace) Export(post rspace.ExportPost, waitForComplete bool, reporter func(string)) (*rspace.Job, error) {
+	m.mutex.Lock()
+	archive, err := m.exportArchive(post)
+	if err != nil {
+		m.mutex.Unlock()
+		return nil, err
+	}
+	job := &memoryJob{&rspace.Job{Id: m.nextId("JB").Id, Status: "STARTING"}, archive}
+	m.jobs = append(m.jobs, job)
+	m.mutex.Unlock()
+	if !waitForComplete {
+		started := *job.job
+		return &started, nil
+	}
+	for {
+		polled, err := m.GetJob(job.job.Id)
+		if err != nil || polled.IsCompleted() {
+			return polled, err
+		}
+		reporter(fmt.Sprintf("%s, %.0f%% complete", polled.Status, polled.PercentComplete))
+	}
+}
+
+// exportArchive creates a zip in the layout of an HTML export, with a page for each document
+func (m *memoryRSpace) exportArchive(post rspace.ExportPost) ([]byte, error) {
+	docs := make([]*rspace.Document, 0)
+	if post.Scope == rspace.SELECTION_EXPORT_SCOPE {
+		for _, id := range post.ItemIds {
+			if _, doc := m.documentById(id); doc != nil {
+				docs = append(docs, doc)
+			} else if m.folderById(id) != nil {
+				docs = append(docs, m.documentsInFolder(id)...)
+			} else {
+				return nil, memoryNotFound("Document or folder", id)
+			}
+		}
+	} else {
+		docs = m.documents
+	}
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, doc := range docs {
+		w, err := zw.Create(fmt.Sprintf("%s/%s.html", doc.GlobalId, doc.GlobalId))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(w, "<html><head><title>%s</title></head><body><table>", html.EscapeString(doc.Name))
+		metadata := [][]string{{"Name", doc.Name}, {"Owner", doc.UserInfo.Username}, {"Created", doc.Created},
+			{"Last modified", doc.LastModified}, {"Tags", doc.Tags}, {"Form", doc.Form.Name}}
+		for _, row := range metadata {
+			fmt.Fprintf(w, "<tr><td>%s</td><td>%s</td></tr>", row[0], html.EscapeString(row[1]))
+		}
+		io.WriteString(w, "</table>")
+		for _, field := range doc.Fields {
+			fmt.Fprintf(w, "<h2>%s</h2>%s", html.EscapeString(field.Name), field.Content)
+		}
+		io.WriteString(w, "</body></html>")
+	}
+	err := zw.Close()
+	return buf.Bytes(), err
+}
+
+// documentsInFolder finds the documents in a folder and its subfolders
+func (m *memoryRSpace) documentsInFolder(folderId int) []*rspace.Document {
+	docs := make([]*rspace.Document, 0)
+	for _, doc := range m.documents {
+		if doc.ParentFolderId == folderId {
+			docs = append(docs, doc)
+		}
+	}
+	for _, folder := range m.folders {
+		if folder.ParentFolderId == folderId {
+			docs = append(docs, m.documentsInFolder(folder.Id)...)
+		}
+	}
+	return docs
+}
+
+func exportArchiveName(jobId int) string {
+	return fmt.Sprintf("export-%d.zip", jobId)
+}
+
+// GetJob reports a job's progress, which advances each time it is polled
+func (m *memoryRSpace) GetJob(jobId int) (*rspace.Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, job := range m.jobs {
+		if job.job.Id != jobId {
+			continue
+		}
+		if !job.job.IsCompleted() {
+			job.job.PercentComplete += MEMORY_JOB_PROGRESS_STEP
+			job.job.Status = "RUNNING"
+		}
+		if job.job.PercentComplete >= 100 && !job.job.IsCompleted() {
+			job.job.PercentComplete = 100
+			job.job.Status = "COMPLETED"
+			job.job.Result = &rspace.JobResult{Size: len(job.archive)}
+			job.job.Links = []rspace.Link{rspace.Link{Link: m.baseUrl + "/export/" + exportArchiveName(jobId), Rel: "enclosure"}}
+		}
+		polled := *job.job
+		return &polled, nil
+	}
+	return nil, memoryNotFound("Job", jobId)
+}
+
+// DownloadExport writes the archive of a completed job
+func (m *memoryRSpace) DownloadExport(url *url.URL, outWriter io.Writer) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, job := range m.jobs {
+		if job.job.IsCompleted() && url != nil && path.Base(url.Path) == exportArchiveName(job.job.Id) {
+			_, err := outWriter.Write(job.archive)
+			return err
+		}
+	}
+	return newCliError(EXIT_NOT_FOUND, fmt.Sprintf("No export to download from %v", url))
+}
+
+// groups and users
+
+func (m *memoryRSpace) Groups() (*rspace.GroupList, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return &rspace.GroupList{Groups: m.groups}, nil
+}
+
+// GroupNew creates a group from a post with a display name and members, each with a username and role
+func (m *memoryRSpace) GroupNew(post *rspace.GroupPost) (*rspace.GroupInfo, error) {
+	return m.newGroup(jsonFields(post))
+}
+
+func (m *memoryRSpace) newGroup(fields map[string]interface{}) (*rspace.GroupInfo, error) {
+	name := stringField(fields, "displayname", "name")
+	if len(name) == 0 {
+		name = "Untitled group"
+	}
+	usernames := make([]string, 0)
+	members, _ := fields["members"].([]interface{})
+	for _, member := range members {
+		memberFields := jsonFields(member)
+		username := stringField(memberFields, "username")
+		// the PI is listed first
+		if stringField(memberFields, "roleingroup") == "PI" {
+			usernames = append([]string{username}, usernames...)
+		} else {
+			usernames = append(usernames, username)
+		}
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.addGroup(name, usernames...)
+}
+
+func (m *memoryRSpace) Users(lastLoginBefore, creationDateBefore time.Time, cfg rspace.RecordListingConfig) (*rspace.UserList, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	users := make([]rspace.UserInfo, 0)
+	for _, user := range m.users {
+		users = append(users, *user)
+	}
+	from, to := pageBounds(len(users), cfg)
+	return &rspace.UserList{Users: users[from:to], TotalHits: len(users), PageNumber: cfg.PageNumber}, nil
+}
+
+func (m *memoryRSpace) UserNew(post *rspace.UserPost) (*rspace.UserInfo, error) {
+	return m.newUser(jsonFields(post))
+}
+
+func (m *memoryRSpace) newUser(fields map[string]interface{}) (*rspace.UserInfo, error) {
+	username := stringField(fields, "username")
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(username) == 0 {
+		username = fmt.Sprintf("user%d", len(m.users)+1)
+	}
+	if m.userByName(username) != nil {
+		return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("User '%s' already exists", username))
+	}
+	user := m.addUser(username, stringField(fields, "email"))
+	user.FirstName = stringField(fields, "firstname")
+	user.LastName = stringField(fields, "lastname")
+	return user, nil
+}
+
+// addDemoContent creates sample users, a group, a structured form, a notebook, documents
+// and Gallery files, so there is something to list, share and export
+func (m *memoryRSpace) addDemoContent() {
+	m.mutex.Lock()
+	m.addUser("user2", "user2@example.com")
+	m.addGroup("Demo lab", "user1", "user2")
+	form := m.addForm("Experiment", []rspace.FormField{memoryFormField("Objective", "Text"),
+		memoryFormField("Temperature", "Number"), memoryFormField("Date", "Date")})
+	form.FormState = "PUBLISHED"
+	experiments := m.addFolder("Experiments", false, m.homeFolderId)
+	notebook := m.addFolder("Lab notebook", true, m.homeFolderId)
+	m.mutex.Unlock()
+
+	for i := 1; i <= 3; i++ {
+		m.NewDocumentWithContent(&rspace.DocumentPost{Name: fmt.Sprintf("Day %d", i), Tags: "demo,notebook",
+			ParentFolderId: notebook.Id, Fields: []rspace.FieldContent{rspace.FieldContent{
+				Content: fmt.Sprintf("<p>Notebook entry for day %d</p>", i)}}})
+	}
+	m.NewDocumentWithContent(&rspace.DocumentPost{Name: "PCR run", Tags: "demo,pcr", FormID: rspace.FormId{Id: form.Id},
+		ParentFolderId: experiments.Id, Fields: []rspace.FieldContent{rspace.FieldContent{Content: "<p>Amplify target gene</p>"},
+			rspace.FieldContent{Content: "72"}, rspace.FieldContent{Content: "2020-06-01"}}})
+	m.NewBasicDocumentWithContent("Meeting notes", "demo", "<p>Agreed the protocol for next week</p>")
+	m.addFile("results.csv", "PCR results", []byte("sample,ct\nA1,21.3\nA2,22.8\n"))
+	m.addFile("protocol.txt", "PCR protocol", []byte("1. Denature at 95C\n2. Anneal at 55C\n3. Extend at 72C\n"))
+}
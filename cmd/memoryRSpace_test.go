@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+// newMemoryContext runs commands against an in-memory RSpace, capturing output and errors
+func newMemoryContext(format string) (*Context, *memoryRSpace, *bytes.Buffer, *bytes.Buffer) {
+	client := newMemoryRSpace()
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	ctx := &Context{WebClient: client, Writer: out, ErrWriter: errOut, Format: outputFmt(format)}
+	return ctx, client, out, errOut
+}
+
+func TestMemoryAddFolderThenListTree(t *testing.T) {
+	ctx, client, out, _ := newMemoryContext("quiet")
+	err := doAddFolder(ctx, addFolderArg{Name: "Experiments"}, rspace.FolderPost{})
+	if err != nil {
+		t.Fatalf("Expected folder to be created but got %v", err)
+	}
+	folderId := strings.TrimSpace(out.String())
+	parentId, _ := strconv.Atoi(folderId)
+	doc, _ := client.NewDocumentWithContent(&rspace.DocumentPost{Name: "Day1", ParentFolderId: parentId})
+
+	out.Reset()
+	folderIdArg = folderId
+	defer func() { folderIdArg = "" }()
+	if err = doListTree(ctx, rspace.RecordListingConfig{PageSize: 20}); err != nil {
+		t.Fatalf("Expected folder to be listed but got %v", err)
+	}
+	listed := strings.Fields(out.String())
+	if len(listed) != 1 || listed[0] != strconv.Itoa(doc.Id) {
+		t.Errorf("Expected the document in folder %s but got %v", folderId, listed)
+	}
+}
+
+func TestMemoryDocumentInNotebookFolderRejected(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	notebook := client.addFolder("Notebook", true, client.homeFolderId)
+	err := doAddFolder(ctx, addFolderArg{ParentFolder: notebook.GlobalId}, rspace.FolderPost{})
+	if exitCodeFor(err) != EXIT_VALIDATION {
+		t.Errorf("Expected a validation error but got %v", err)
+	}
+	err = doAddFolder(ctx, addFolderArg{ParentFolder: "FL99999"}, rspace.FolderPost{})
+	if exitCodeFor(err) != EXIT_NOT_FOUND {
+		t.Errorf("Expected not found but got %v", err)
+	}
+}
+
+func TestMemoryShareReportsInvalidIds(t *testing.T) {
+	ctx, client, out, errOut := newMemoryContext("quiet")
+	doc, _ := client.NewBasicDocumentWithContent("shared", "", "")
+	group, _ := client.addGroup("lab", "user1")
+	err := doShare(ctx, []string{doc.GlobalId, "XY"}, &shareArgs{permArg: "read", groupIdsArg: strconv.Itoa(group.Id)})
+	if exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected partial failure but got %v", err)
+	}
+	if !strings.Contains(errOut.String(), "XY") {
+		t.Errorf("Expected invalid id to be reported but got %s", errOut.String())
+	}
+	if len(strings.Fields(out.String())) != 1 {
+		t.Errorf("Expected 1 share result but got %s", out.String())
+	}
+}
+
+func TestMemoryExportThenDownloadJob(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("quiet")
+	doc, _ := client.NewBasicDocumentWithContent("exported", "", "<p>results</p>")
+	post := rspace.ExportPost{Format: rspace.HTML_FORMAT, Scope: rspace.SELECTION_EXPORT_SCOPE, ItemIds: []int{doc.Id}}
+	job, err := client.Export(post, false, func(string) {})
+	if err != nil {
+		t.Fatalf("Expected export to start but got %v", err)
+	}
+
+	dir, _ := ioutil.TempDir("", "memoryExport")
+	defer os.RemoveAll(dir)
+	jobCmdArgsArg = jobCmdArgs{Download: true, DownloadPath: filepath.Join(dir, "export.zip")}
+	defer func() { jobCmdArgsArg = jobCmdArgs{} }()
+	args := []string{strconv.Itoa(job.Id)}
+	// the first poll is only half way
+	if err = doJob(ctx, args); err != nil {
+		t.Fatalf("Expected job progress but got %v", err)
+	}
+	if _, err = os.Stat(jobCmdArgsArg.DownloadPath); err == nil {
+		t.Fatalf("Expected no download before the job completed")
+	}
+	if err = doJob(ctx, args); err != nil {
+		t.Fatalf("Expected job download but got %v", err)
+	}
+	result := inspectArchive(ctx, jobCmdArgsArg.DownloadPath, &archiveArgs{summaryArg: true})
+	if result.err != nil || result.summary.DocCount != 1 || result.summary.Authors[0] != "user1" {
+		t.Errorf("Expected archive with 1 document by user1 but got %+v", result)
+	}
+}
+
+func TestMemoryDownloadReportsMissingFiles(t *testing.T) {
+	ctx, client, _, errOut := newMemoryContext("quiet")
+	info := client.addFile("data.csv", "", []byte("a,b\n1,2\n"))
+	dir, _ := ioutil.TempDir("", "memoryDownload")
+	defer os.RemoveAll(dir)
+	dArgs.OutfolderArg = dir
+	defer func() { dArgs.OutfolderArg = "" }()
+
+	err := doDownload(ctx, []int{info.Id, 99999})
+	if exitCodeFor(err) != EXIT_PARTIAL_FAILURE {
+		t.Errorf("Expected partial failure but got %v", err)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(dir, "data.csv")); string(content) != "a,b\n1,2\n" {
+		t.Errorf("Expected downloaded file content but got %q", content)
+	}
+	if !strings.Contains(errOut.String(), "99999") {
+		t.Errorf("Expected missing file to be reported but got %s", errOut.String())
+	}
+}
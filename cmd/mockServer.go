@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+	"github.com/spf13/cobra"
+)
+
+// path that the mock server's API is served from, as for a real RSpace
+const MOCK_SERVER_API_PATH = "/api/v1"
+
+type mockServerArgs struct {
+	port   int
+	apiKey string
+	empty  bool
+}
+
+var mockServerArgsA mockServerArgs
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Runs a local RSpace API server with in-memory content, for testing and demos",
+	Long: `Runs a local server providing a subset of the RSpace API, with content held in memory.
+Documents, folders and notebooks, Gallery files, forms, groups, users, activity, sharing
+and export jobs are supported, so that scripts using the CLI can be tried out, tested in CI or
+used in training sessions without an RSpace server.
+
+Export jobs progress by 50% each time their status is requested, and complete after 2 requests.
+Exports are HTML archives with a page per document.
+
+Unless --empty is set, the server starts with sample content: a notebook, a folder of documents,
+a structured form, Gallery files and a group.
+
+All content is lost when the server stops. Requests are logged to stderr.
+`,
+	Example: `
+// start a server on port 8080
+rspace mock-server
+
+// in another terminal, point the CLI at it and export and download some work
+export RSPACE_URL=http://localhost:8080/api/v1 RSPACE_API_KEY=mock-api-key
+rspace eln listTree
+rspace eln export --scope user --wait -f quiet | xargs rspace eln job --download
+
+// start with no content, on another port and with another API key
+rspace mock-server --empty --port 9000 --apiKey abc123
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseLocalContext()
+		if err != nil {
+			return err
+		}
+		return doMockServer(ctx, mockServerArgsA)
+	},
+}
+
+func doMockServer(ctx *Context, args mockServerArgs) error {
+	if args.port <= 0 || args.port > 65535 {
+		return newCliError(EXIT_VALIDATION, fmt.Sprintf("Invalid port %d", args.port))
+	}
+	if len(args.apiKey) == 0 {
+		return newCliError(EXIT_VALIDATION, "Please supply an API key that clients must use")
+	}
+	memory := newMemoryRSpace()
+	if !args.empty {
+		memory.addDemoContent()
+	}
+	apiUrl := fmt.Sprintf("http://localhost:%d%s", args.port, MOCK_SERVER_API_PATH)
+	memory.baseUrl = apiUrl
+	ctx.messageStdErr(fmt.Sprintf("Mock RSpace API running at %s, press Ctrl-C to stop. To use it, set:", apiUrl))
+	ctx.messageStdErr(fmt.Sprintf("%s=%s\n%s=%s", BASE_URL_ENV_NAME, apiUrl, APIKEY_ENV_NAME, args.apiKey))
+	server := newMockServer(memory, args.apiKey, ctx.errWriter())
+	return http.ListenAndServe(fmt.Sprintf("localhost:%d", args.port), server)
+}
+
+// mockServer serves the RSpace API from a memoryRSpace
+type mockServer struct {
+	rspace *memoryRSpace
+	apiKey string
+	// requests are logged here
+	log io.Writer
+}
+
+func newMockServer(memory *memoryRSpace, apiKey string, log io.Writer) *mockServer {
+	return &mockServer{memory, apiKey, log}
+}
+
+// mockResponse is either JSON, or file content
+type mockResponse struct {
+	status      int
+	body        interface{}
+	content     []byte
+	contentType string
+	fileName    string
+}
+
+func jsonResponse(status int, body interface{}) *mockResponse {
+	return &mockResponse{status: status, body: body}
+}
+
+// mockApiError is the body of an error response, in the format of RSpace API errors
+type mockApiError struct {
+	Status           string   `json:"status"`
+	HttpCode         int      `json:"httpCode"`
+	InternalCode     int      `json:"internalCode"`
+	Message          string   `json:"message"`
+	Errors           []string `json:"errors"`
+	Iso8601Timestamp string   `json:"iso8601Timestamp"`
+}
+
+var httpStatusForExitCode = map[int]int{
+	EXIT_VALIDATION: http.StatusBadRequest,
+	EXIT_AUTH:       http.StatusUnauthorized,
+	EXIT_NOT_FOUND:  http.StatusNotFound,
+	EXIT_CONFLICT:   http.StatusConflict,
+}
+
+func (s *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response, err := s.handle(r)
+	if err != nil {
+		status, ok := httpStatusForExitCode[exitCodeFor(err)]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		statusText := strings.ToUpper(strings.Replace(http.StatusText(status), " ", "_", -1))
+		response = jsonResponse(status, mockApiError{statusText, status, status * 100, err.Error(),
+			[]string{err.Error()}, time.Now().UTC().Format(time.RFC3339)})
+	}
+	fmt.Fprintf(s.log, "%s %s %d\n", r.Method, r.URL.Path, response.status)
+	if response.body == nil {
+		if len(response.fileName) > 0 {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", response.fileName))
+		}
+		w.Header().Set("Content-Type", response.contentType)
+		w.WriteHeader(response.status)
+		w.Write(response.content)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.status)
+	json.NewEncoder(w).Encode(response.body)
+}
+
+// handle checks the API key, then routes the request by method and path
+func (s *mockServer) handle(r *http.Request) (*mockResponse, error) {
+	if !strings.HasPrefix(r.URL.Path, MOCK_SERVER_API_PATH+"/") {
+		return nil, newCliError(EXIT_NOT_FOUND, "No API at "+r.URL.Path)
+	}
+	if r.Header.Get("apiKey") != s.apiKey {
+		return nil, newCliError(EXIT_AUTH, "Unauthorized: invalid or missing API key")
+	}
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, MOCK_SERVER_API_PATH), "/"), "/")
+	route := r.Method + " " + path[0]
+	query := r.URL.Query()
+	m := s.rspace
+	switch {
+	case route == "GET status":
+		return okResponse(m.Status())
+	case route == "GET documents" && len(path) == 1:
+		if len(query.Get("advancedQuery")) > 0 {
+			return okResponse(m.AdvancedSearchDocuments(mockListingConfig(query), &rspace.SearchQuery{}))
+		}
+		return okResponse(m.SearchDocuments(mockListingConfig(query), query.Get("query")))
+	case route == "POST documents":
+		post := &rspace.DocumentPost{}
+		if err := decodeJsonBody(r, post); err != nil {
+			return nil, err
+		}
+		return createdResponse(m.NewDocumentWithContent(post))
+	case route == "GET documents":
+		return withId(path, func(id int) (*mockResponse, error) { return okResponse(m.DocumentById(id)) })
+	case route == "PUT documents":
+		return withId(path, func(id int) (*mockResponse, error) {
+			post := &rspace.DocumentPost{}
+			if err := decodeJsonBody(r, post); err != nil {
+				return nil, err
+			}
+			return okResponse(m.DocumentEdit(id, post))
+		})
+	case route == "DELETE documents":
+		return withId(path, func(id int) (*mockResponse, error) {
+			if _, err := m.DeleteDocument(id); err != nil {
+				return nil, err
+			}
+			return &mockResponse{status: http.StatusNoContent}, nil
+		})
+	case route == "POST folders":
+		post := &rspace.FolderPost{}
+		if err := decodeJsonBody(r, post); err != nil {
+			return nil, err
+		}
+		return createdResponse(m.FolderNew(post))
+	case route == "GET folders" && len(path) > 1 && path[1] == "tree":
+		folderId := 0
+		if len(path) > 2 {
+			folderId, _ = strconv.Atoi(path[2])
+		}
+		types := make([]string, 0)
+		if len(query.Get("typesToInclude")) > 0 {
+			types = strings.Split(query.Get("typesToInclude"), ",")
+		}
+		return okResponse(m.FolderTree(mockListingConfig(query), folderId, types))
+	case route == "GET folders":
+		return withId(path, func(id int) (*mockResponse, error) { return okResponse(m.FolderById(id)) })
+	case route == "GET files" && len(path) == 1:
+		return okResponse(m.Files(mockListingConfig(query), query.Get("mediaType")))
+	case route == "GET files":
+		return withId(path, func(id int) (*mockResponse, error) { return s.file(id, len(path) > 2 && path[2] == "file") })
+	case route == "POST files":
+		name, content, err := multipartFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResponse(http.StatusCreated, m.addFile(name, r.FormValue("caption"), content)), nil
+	case route == "GET forms" && len(path) == 1:
+		return okResponse(m.Forms(mockListingConfig(query)))
+	case route == "GET forms":
+		return withId(path, func(id int) (*mockResponse, error) { return okResponse(m.FormById(id)) })
+	case route == "POST forms":
+		if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+			return createdResponse(m.CreateFormYaml(r.Body))
+		}
+		return createdResponse(m.CreateFormJson(r.Body))
+	case route == "PUT forms" && len(path) == 3 && path[2] == "publish":
+		return withId(path, func(id int) (*mockResponse, error) { return okResponse(m.PublishForm(id)) })
+	case route == "GET activity":
+		return okResponse(m.Activities(&rspace.ActivityQuery{}, mockListingConfig(query)))
+	case route == "POST share":
+		post := &rspace.SharePost{}
+		if err := decodeJsonBody(r, post); err != nil {
+			return nil, err
+		}
+		return createdResponse(m.Share(post))
+	case route == "POST export":
+		post, err := mockExportPost(path, query)
+		if err != nil {
+			return nil, err
+		}
+		job, err := m.Export(post, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResponse(http.StatusAccepted, job), nil
+	case route == "GET export":
+		return s.exportArchive(r.URL)
+	case route == "GET jobs":
+		return withId(path, func(id int) (*mockResponse, error) { return okResponse(m.GetJob(id)) })
+	case route == "GET groups":
+		return okResponse(m.Groups())
+	case route == "POST import" && len(path) == 2 && path[1] == "word":
+		name, _, err := multipartFile(r)
+		if err != nil {
+			return nil, err
+		}
+		folderId, _ := strconv.Atoi(r.FormValue("folderId"))
+		return createdResponse(m.importDocument(name, folderId))
+	case route == "GET sysadmin" && len(path) == 2 && path[1] == "users":
+		return okResponse(m.Users(time.Time{}, time.Time{}, mockListingConfig(query)))
+	case route == "POST sysadmin" && len(path) == 2:
+		fields, err := decodeJsonFields(r)
+		if err != nil {
+			return nil, err
+		}
+		if path[1] == "users" {
+			return createdResponse(m.newUser(fields))
+		} else if path[1] == "groups" {
+			return createdResponse(m.newGroup(fields))
+		}
+	}
+	return nil, newCliError(EXIT_NOT_FOUND, fmt.Sprintf("%s %s is not supported by the mock server", r.Method, r.URL.Path))
+}
+
+func okResponse(body interface{}, err error) (*mockResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	return jsonResponse(http.StatusOK, body), nil
+}
+
+func createdResponse(body interface{}, err error) (*mockResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	return jsonResponse(http.StatusCreated, body), nil
+}
+
+// withId handles requests for a single item, whose id follows the resource name in the path
+func withId(path []string, handler func(id int) (*mockResponse, error)) (*mockResponse, error) {
+	if len(path) < 2 {
+		return nil, newCliError(EXIT_VALIDATION, "Please specify an id")
+	}
+	id, err := strconv.Atoi(path[1])
+	if err != nil || id <= 0 {
+		return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("Invalid id '%s'", path[1]))
+	}
+	return handler(id)
+}
+
+func decodeJsonBody(r *http.Request, target interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(target); err != nil {
+		return newCliError(EXIT_VALIDATION, "Invalid JSON request body: "+err.Error())
+	}
+	return nil
+}
+
+// decodeJsonFields reads a JSON object, with its field names in lower case
+func decodeJsonFields(r *http.Request) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if err := decodeJsonBody(r, &fields); err != nil {
+		return nil, err
+	}
+	return jsonFields(fields), nil
+}
+
+// multipartFile reads the name and content of an uploaded file
+func multipartFile(r *http.Request) (string, []byte, error) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return "", nil, newCliError(EXIT_VALIDATION, "No file uploaded: "+err.Error())
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	return header.Filename, content, err
+}
+
+// mockListingConfig reads paging and ordering parameters, e.g. 'orderBy=name asc'
+func mockListingConfig(query url.Values) rspace.RecordListingConfig {
+	cfg := rspace.NewRecordListingConfig()
+	if pageNumber, err := strconv.Atoi(query.Get("pageNumber")); err == nil {
+		cfg.PageNumber = pageNumber
+	}
+	if pageSize, err := strconv.Atoi(query.Get("pageSize")); err == nil {
+		cfg.PageSize = pageSize
+	}
+	if orderBy := strings.Fields(query.Get("orderBy")); len(orderBy) > 0 {
+		cfg.OrderBy = orderBy[0]
+		if len(orderBy) > 1 {
+			cfg.SortOrder = orderBy[1]
+		}
+	}
+	return cfg
+}
+
+// mockExportPost reads an export request of the form 'export/{format}/{scope}/{id}'. Selections
+// are identified by the 'selections' parameter, a comma-separated list of ids.
+func mockExportPost(path []string, query url.Values) (rspace.ExportPost, error) {
+	post := rspace.ExportPost{}
+	if len(path) < 3 {
+		return post, newCliError(EXIT_VALIDATION, "Please specify an export format and scope")
+	}
+	switch path[1] {
+	case "html":
+		post.Format = rspace.HTML_FORMAT
+	case "xml":
+		post.Format = rspace.XML_FORMAT
+	default:
+		return post, newCliError(EXIT_VALIDATION, "Unknown export format "+path[1])
+	}
+	switch path[2] {
+	case "user":
+		post.Scope = rspace.USER_EXPORT_SCOPE
+	case "group":
+		post.Scope = rspace.GROUP_EXPORT_SCOPE
+	case "selection":
+		post.Scope = rspace.SELECTION_EXPORT_SCOPE
+	default:
+		return post, newCliError(EXIT_VALIDATION, "Unknown export scope "+path[2])
+	}
+	if len(path) > 3 {
+		post.Id, _ = strconv.Atoi(path[3])
+	}
+	for _, idStr := range strings.Split(query.Get("selections"), ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(idStr)); err == nil {
+			post.ItemIds = append(post.ItemIds, id)
+		}
+	}
+	if post.Scope == rspace.SELECTION_EXPORT_SCOPE && len(post.ItemIds) == 0 {
+		return post, newCliError(EXIT_VALIDATION, "Please specify the items to export with the 'selections' parameter")
+	}
+	post.MaxLinkLevel, _ = strconv.Atoi(query.Get("maxLinkLevel"))
+	return post, nil
+}
+
+// file returns a Gallery file's details, or its content
+func (s *mockServer) file(id int, content bool) (*mockResponse, error) {
+	s.rspace.mutex.Lock()
+	defer s.rspace.mutex.Unlock()
+	file := s.rspace.fileById(id)
+	if file == nil {
+		return nil, memoryNotFound("File", id)
+	}
+	if content {
+		return &mockResponse{status: http.StatusOK, content: file.content, contentType: file.info.ContentType,
+			fileName: file.info.Name}, nil
+	}
+	return jsonResponse(http.StatusOK, file.info), nil
+}
+
+func (s *mockServer) exportArchive(archiveUrl *url.URL) (*mockResponse, error) {
+	buf := &bytes.Buffer{}
+	if err := s.rspace.DownloadExport(archiveUrl, buf); err != nil {
+		return nil, err
+	}
+	return &mockResponse{status: http.StatusOK, content: buf.Bytes(), contentType: "application/zip",
+		fileName: path.Base(archiveUrl.Path)}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(mockServerCmd)
+	mockServerCmd.Flags().IntVar(&mockServerArgsA.port, "port", 8080, "Port to listen on")
+	mockServerCmd.Flags().StringVar(&mockServerArgsA.apiKey, "apiKey", "mock-api-key", "API key that clients must send")
+	mockServerCmd.Flags().BoolVar(&mockServerArgsA.empty, "empty", false, "Start with no sample content")
+}
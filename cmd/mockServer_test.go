@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+const testApiKey = "test-key"
+
+func newTestMockServer() (*httptest.Server, *memoryRSpace) {
+	memory := newMemoryRSpace()
+	server := httptest.NewServer(newMockServer(memory, testApiKey, ioutil.Discard))
+	memory.baseUrl = server.URL + MOCK_SERVER_API_PATH
+	return server, memory
+}
+
+// mockRequest makes an authenticated API request, decoding any JSON response into result
+func mockRequest(t *testing.T, method, url, contentType string, body io.Reader, result interface{}) *http.Response {
+	req, _ := http.NewRequest(method, url, body)
+	req.Header.Set("apiKey", testApiKey)
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request to %s failed: %v", url, err)
+	}
+	if result != nil {
+		defer resp.Body.Close()
+		json.NewDecoder(resp.Body).Decode(result)
+	}
+	return resp
+}
+
+func TestMockServerRequiresApiKey(t *testing.T) {
+	server, _ := newTestMockServer()
+	defer server.Close()
+	resp, err := http.Get(server.URL + MOCK_SERVER_API_PATH + "/status")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	apiErr := mockApiError{}
+	json.NewDecoder(resp.Body).Decode(&apiErr)
+	if resp.StatusCode != http.StatusUnauthorized || apiErr.HttpCode != 401 {
+		t.Errorf("Expected 401 but got %d, %+v", resp.StatusCode, apiErr)
+	}
+}
+
+func TestMockServerCreateAndGetDocument(t *testing.T) {
+	server, _ := newTestMockServer()
+	defer server.Close()
+	api := server.URL + MOCK_SERVER_API_PATH
+	post := `{"name":"doc1","tags":"a,b","fields":[{"content":"<p>hello</p>"}]}`
+	created := rspace.Document{}
+	resp := mockRequest(t, "POST", api+"/documents", "application/json", strings.NewReader(post), &created)
+	if resp.StatusCode != http.StatusCreated || created.Name != "doc1" {
+		t.Fatalf("Expected document to be created but got %d, %+v", resp.StatusCode, created)
+	}
+
+	got := rspace.Document{}
+	mockRequest(t, "GET", fmt.Sprintf("%s/documents/%d", api, created.Id), "", nil, &got)
+	if got.Tags != "a,b" || len(got.Fields) != 1 || got.Fields[0].Content != "<p>hello</p>" {
+		t.Errorf("Expected created document but got %+v", got)
+	}
+	resp = mockRequest(t, "GET", api+"/documents/99999", "", nil, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 but got %d", resp.StatusCode)
+	}
+}
+
+func TestMockServerUploadAndDownloadFile(t *testing.T) {
+	server, _ := newTestMockServer()
+	defer server.Close()
+	api := server.URL + MOCK_SERVER_API_PATH
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "data.txt")
+	part.Write([]byte("some data"))
+	writer.WriteField("caption", "my data")
+	writer.Close()
+
+	uploaded := rspace.FileInfo{}
+	mockRequest(t, "POST", api+"/files", writer.FormDataContentType(), body, &uploaded)
+	if uploaded.Name != "data.txt" || uploaded.Caption != "my data" || uploaded.Size != 9 {
+		t.Fatalf("Expected uploaded file info but got %+v", uploaded)
+	}
+	resp := mockRequest(t, "GET", fmt.Sprintf("%s/files/%d/file", api, uploaded.Id), "", nil, nil)
+	defer resp.Body.Close()
+	if content, _ := ioutil.ReadAll(resp.Body); string(content) != "some data" {
+		t.Errorf("Expected file content but got %q", content)
+	}
+}
+
+func TestMockServerExportJobProgressesToDownload(t *testing.T) {
+	server, memory := newTestMockServer()
+	defer server.Close()
+	api := server.URL + MOCK_SERVER_API_PATH
+	memory.addDemoContent()
+
+	job := rspace.Job{}
+	resp := mockRequest(t, "POST", api+"/export/html/user/1", "", nil, &job)
+	if resp.StatusCode != http.StatusAccepted || job.IsCompleted() {
+		t.Fatalf("Expected job to be started but got %d, %+v", resp.StatusCode, job)
+	}
+	for i := 0; i < 2; i++ {
+		mockRequest(t, "GET", fmt.Sprintf("%s/jobs/%d", api, job.Id), "", nil, &job)
+	}
+	if !job.IsCompleted() || job.DownloadLink() == nil {
+		t.Fatalf("Expected job to be completed after 2 polls but got %+v", job)
+	}
+
+	resp = mockRequest(t, "GET", job.DownloadLink().String(), "", nil, nil)
+	defer resp.Body.Close()
+	archive, _ := ioutil.ReadAll(resp.Body)
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("Expected a zip file but got %v", err)
+	}
+	summary, err := summarise(&Context{}, reader)
+	if err != nil || summary.DocCount != 5 || summary.FormCounts["Experiment"] != 1 {
+		t.Errorf("Expected 5 documents in export but got %+v, %v", summary, err)
+	}
+}
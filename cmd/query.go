@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// JMESPath (https://jmespath.org) applied to the JSON form of a result, so that
+// results can be filtered and reshaped without needing jq, e.g.
+//
+//	Documents[?Tags == 'pcr' && Form.GlobalId != 'FM1'].{id: GlobalId, name: Name}
+
+// compileQuery parses a JMESPath expression
+func compileQuery(query string) (*jmespath.JMESPath, error) {
+	expression, err := jmespath.Compile(query)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid --jmespath '%s': %s", query, err.Error()))
+	}
+	return expression, nil
+}
+
+// queryFormatter formats the result of a query applied to another formatter's JSON
+type queryFormatter struct {
+	result interface{}
+}
+
+func newQueryFormatter(formatter ResultListFormatter, query string) (*queryFormatter, error) {
+	expression, err := compileQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(formatter.ToJson()), &value); err != nil {
+		return nil, err
+	}
+	result, err := expression.Search(value)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Couldn't apply --jmespath '%s': %s", query, err.Error()))
+	}
+	return &queryFormatter{result}, nil
+}
+
+func (qf *queryFormatter) ToJson() string {
+	return prettyMarshal(qf.result)
+}
+
+// isScalar is true if the query result is a single string, number, boolean or null
+func (qf *queryFormatter) isScalar() bool {
+	switch qf.result.(type) {
+	case []interface{}, map[string]interface{}:
+		return false
+	}
+	return true
+}
+
+func (qf *queryFormatter) rows() []interface{} {
+	if list, ok := qf.result.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{qf.result}
+}
+
+// ids of results with an 'Id' field, or else the values themselves
+func (qf *queryFormatter) ToQuiet() []identifiable {
+	rows := make([]identifiable, 0)
+	for _, row := range qf.rows() {
+		if obj, ok := row.(map[string]interface{}); ok {
+			if id, found := obj["Id"]; found {
+				row = id
+			}
+		}
+		rows = append(rows, identifiable{queryValueString(row)})
+	}
+	return rows
+}
+
+// objects have a column for each key, in alphabetical order; other values are shown in a single 'Value' column
+func (qf *queryFormatter) ToTable() *TableResult {
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+	hasScalars := false
+	for _, row := range qf.rows() {
+		if obj, ok := row.(map[string]interface{}); ok {
+			for key := range obj {
+				if !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+		} else {
+			hasScalars = true
+		}
+	}
+	sort.Strings(columns)
+	if hasScalars || len(columns) == 0 {
+		columns = append([]string{"Value"}, columns...)
+	}
+	headers := make([]columnDef, 0)
+	for _, column := range columns {
+		headers = append(headers, columnDef{column, displayWidth(column)})
+	}
+	content := make([][]string, 0)
+	for _, row := range qf.rows() {
+		cells := make([]string, 0)
+		for i, column := range columns {
+			cell := ""
+			if obj, ok := row.(map[string]interface{}); ok {
+				cell = queryValueString(obj[column])
+			} else if column == "Value" {
+				cell = queryValueString(row)
+			}
+			if displayWidth(cell) > headers[i].Width {
+				headers[i].Width = int(math.Min(float64(displayWidth(cell)), MAX_SELECTED_COLUMN_WIDTH))
+			}
+			cells = append(cells, cell)
+		}
+		content = append(content, cells)
+	}
+	return &TableResult{headers, content}
+}
+
+func queryValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	bytes, _ := json.Marshal(value)
+	return string(bytes)
+}
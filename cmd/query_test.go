@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+func TestInvalidQuery(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("table"), Query: "Documents[?Id = 1]"}
+	if err := ctx.writeResult(docPage()); err == nil || !strings.Contains(err.Error(), "Invalid --jmespath") {
+		t.Errorf("Expected an invalid query to be reported but got %v", err)
+	}
+}
+
+func TestQueryOutput(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("table"), Query: "TotalHits"}
+	ctx.writeResult(docPage())
+	assertEqualString(t, "1\n", ctx.Writer.(*bytes.Buffer).String())
+
+	ctx = &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("csv"),
+		Query: "Documents[*].{id: GlobalId, tags: Tags}"}
+	ctx.writeResult(docPage())
+	assertEqualString(t, "id,tags\nSD12,\"pcr,mouse\"\n", ctx.Writer.(*bytes.Buffer).String())
+
+	ctx = &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("quiet"), Query: "Documents[?Tags != 'x']"}
+	ctx.writeResult(docPage())
+	assertEqualString(t, "12\n", ctx.Writer.(*bytes.Buffer).String())
+
+	ctx = &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("json"),
+		Query: "sort_by(Documents, &Name)[?contains(Tags, 'pcr')].GlobalId"}
+	ctx.writeResult(docPage())
+	assertEqualString(t, "[\n\t\"SD12\"\n]\n", ctx.Writer.(*bytes.Buffer).String())
+}
+
+func TestStreamWithQueryCombinesPages(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("table"), Query: "length(Forms)"}
+	stream := ctx.newResultStream()
+	stream.writePage(formPage(3, "a", "b"))
+	stream.writePage(formPage(3, "c"))
+	stream.close()
+	assertEqualString(t, "3\n", ctx.Writer.(*bytes.Buffer).String())
+}
+
+func TestListDocumentsWithSearchAndQuery(t *testing.T) {
+	defer func() { searchQuery = "" }()
+	ctx, client, out, _ := newMemoryContext("quiet")
+	client.NewBasicDocumentWithContent("PCR 1", "pcr", "")
+	client.NewBasicDocumentWithContent("PCR 2", "pcr,gel", "")
+	client.NewBasicDocumentWithContent("Notes", "", "")
+	// --query searches RSpace; --jmespath filters the results
+	searchQuery = "pcr"
+	ctx.Query = "Documents[?Tags == 'pcr'].Name"
+	if err := doListDocs(ctx, rspace.RecordListingConfig{PageSize: 20}); err != nil {
+		t.Fatal(err)
+	}
+	assertEqualString(t, "PCR 1\n", out.String())
+}
@@ -17,7 +17,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
@@ -39,10 +38,24 @@ RSPACE_URL=https://myrspace.org/api/v1
 Alternatively set these as environment variables.
 
 To see all the ELN commands run rspace eln --help
+
+When the output format is 'json' or 'jsonl', errors are written to stderr as JSON objects, e.g.
+{"error":{"code":4,"type":"notFound","message":"..."}}
+
+` + exitCodesHelp + `
 `,
 	//	Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		commandStarted = true
+	},
+	// errors returned by commands are reported by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
+// set once cobra has validated a command's flags and arguments, and the command starts running
+var commandStarted = false
+
 // NewOperatorCommand returns the `quarks-job` command.
 func NewOperatorCommand() *cobra.Command {
 	return rootCmd
@@ -51,10 +64,12 @@ func NewOperatorCommand() *cobra.Command {
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		if !commandStarted {
+			err = newCliError(EXIT_VALIDATION, fmt.Sprintf("%s\nRun '%s --help' for usage", err.Error(), cmd.CommandPath()))
+		}
+		exitWithErr(err)
 	}
 }
 
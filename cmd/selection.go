@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// maximum width of a column chosen with --columns
+const MAX_SELECTED_COLUMN_WIDTH = 40
+
+// resultItems is implemented by formatters of result lists, giving access to each
+// result struct, so that --columns and --template can use any of their fields.
+type resultItems interface {
+	items() []interface{}
+}
+
+// formatterItems returns the underlying results of a formatter. If the formatter doesn't
+// expose its results, they are read back from its JSON output.
+func formatterItems(formatter ResultListFormatter) ([]interface{}, error) {
+	if lister, ok := formatter.(resultItems); ok {
+		return lister.items(), nil
+	}
+	raw, err := jsonItems([]byte(formatter.ToJson()))
+	if err != nil {
+		return nil, err
+	}
+	rc := make([]interface{}, 0)
+	for _, item := range raw {
+		var value interface{}
+		if err := json.Unmarshal(item, &value); err != nil {
+			return nil, err
+		}
+		rc = append(rc, value)
+	}
+	return rc, nil
+}
+
+// parses a comma-separated list of column names
+func parseColumns(columnsArg string) []string {
+	rc := make([]string, 0)
+	for _, column := range strings.Split(columnsArg, ",") {
+		if column = strings.TrimSpace(column); len(column) > 0 {
+			rc = append(rc, column)
+		}
+	}
+	return rc
+}
+
+// selectColumns tabulates the named fields of each result. Field names are case-insensitive
+// and nested fields are separated by '.', e.g. 'form.globalId'
+func selectColumns(formatter ResultListFormatter, columns []string) (*TableResult, error) {
+	results, err := formatterItems(formatter)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]columnDef, 0)
+	for _, column := range columns {
+		headers = append(headers, columnDef{column, displayWidth(column)})
+	}
+	rows := make([][]string, 0)
+	for _, result := range results {
+		row := make([]string, 0)
+		for i, column := range columns {
+			value, found := lookupField(reflect.ValueOf(result), strings.Split(column, "."))
+			if !found {
+				return nil, errors.New(fmt.Sprintf("Unknown column '%s'", column))
+			}
+			cell := formatFieldValue(value)
+			if displayWidth(cell) > headers[i].Width {
+				headers[i].Width = displayWidth(cell)
+				if headers[i].Width > MAX_SELECTED_COLUMN_WIDTH {
+					headers[i].Width = MAX_SELECTED_COLUMN_WIDTH
+				}
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+	return &TableResult{headers, rows}, nil
+}
+
+// lookupField finds a field by name or JSON name, ignoring case. Fields of embedded structs
+// are found as well, as they are in templates.
+func lookupField(v reflect.Value, path []string) (reflect.Value, bool) {
+	if len(path) == 0 {
+		return v, true
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			// a missing nested value is shown as empty
+			return reflect.Value{}, true
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if strings.EqualFold(fmt.Sprintf("%v", key.Interface()), path[0]) {
+				return lookupField(v.MapIndex(key), path[1:])
+			}
+		}
+	case reflect.Struct:
+		if field, ok := structField(v, path[0]); ok {
+			return lookupField(field, path[1:])
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func structField(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		jsonName := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if strings.EqualFold(t.Field(i).Name, name) || (len(jsonName) > 0 && strings.EqualFold(jsonName, name)) {
+			return v.Field(i), true
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).Anonymous {
+			continue
+		}
+		embedded := v.Field(i)
+		if embedded.Kind() == reflect.Ptr {
+			if embedded.IsNil() {
+				continue
+			}
+			embedded = embedded.Elem()
+		}
+		if embedded.Kind() == reflect.Struct {
+			if field, ok := structField(embedded, name); ok {
+				return field, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func formatFieldValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return formatFieldValue(v.Elem())
+	case reflect.String:
+		return v.String()
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice, reflect.Array:
+		values := make([]string, 0)
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, formatFieldValue(v.Index(i)))
+		}
+		return strings.Join(values, ",")
+	case reflect.Struct, reflect.Map:
+		bytes, _ := json.Marshal(v.Interface())
+		return string(bytes)
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+// parseOutputTemplate parses a --template argument. Each result is written on its own line.
+func parseOutputTemplate(templateArg string) (*template.Template, error) {
+	if len(templateArg) == 0 {
+		return nil, errors.New("Please supply a template with --template when using '-f template'")
+	}
+	tmpl, err := template.New("output").Option("missingkey=zero").Parse(templateArg)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid template: %s", err.Error()))
+	}
+	return tmpl, nil
+}
+
+func executeOutputTemplate(tmpl *template.Template, formatter ResultListFormatter) ([]string, error) {
+	results, err := formatterItems(formatter)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0)
+	for _, result := range results {
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, result); err != nil {
+			return nil, err
+		}
+		lines = append(lines, out.String())
+	}
+	return lines, nil
+}
+
+func printTemplate(ctx *Context, formatter ResultListFormatter) error {
+	tmpl, err := parseOutputTemplate(ctx.Template)
+	if err != nil {
+		return err
+	}
+	lines, err := executeOutputTemplate(tmpl, formatter)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		ctx.write(line)
+	}
+	return nil
+}
+
+// toTable tabulates the columns chosen with --columns, or the formatter's default columns
+func (ctx *Context) toTable(formatter ResultListFormatter) (*TableResult, error) {
+	if len(ctx.Columns) == 0 {
+		return formatter.ToTable(), nil
+	}
+	return selectColumns(formatter, ctx.Columns)
+}
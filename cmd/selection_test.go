@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+func docPage() *DocListFormatter {
+	namable := &rspace.IdentifiableNamable{Id: 12, GlobalId: "SD12", Name: "PCR run"}
+	doc := rspace.DocumentInfo{IdentifiableNamable: namable, Tags: "pcr,mouse",
+		Form: rspace.FormInfo{GlobalId: "FM3"}, UserInfo: rspace.UserInfo{Username: "user1"}}
+	return &DocListFormatter{&rspace.DocumentList{Documents: []rspace.DocumentInfo{doc}, TotalHits: 1}}
+}
+
+func TestSelectColumns(t *testing.T) {
+	table, err := selectColumns(docPage(), parseColumns("name, globalId,tags,form.globalId,owner.username"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualString(t, "name,globalId,tags,form.globalId,owner.username", strings.Join(columnDefsToString(table.Headers), ","))
+	assertEqualString(t, "PCR run,SD12,pcr,mouse,FM3,user1", strings.Join(table.Content[0], ","))
+	if table.Headers[0].Width != len("PCR run") {
+		t.Fatalf("Column should fit its content but was %d", table.Headers[0].Width)
+	}
+
+	if _, err = selectColumns(docPage(), []string{"unknown"}); err == nil {
+		t.Fatalf("Should fail - unknown column")
+	}
+}
+
+func TestSelectColumnsFromJson(t *testing.T) {
+	// formatters without items() are read from their JSON
+	summary := &importMappingFormatter{&importMappingList{[]*importMapping{&importMapping{OldId: 3, NewGlobalId: "SD5"}}}}
+	table, err := selectColumns(summary, []string{"oldid", "NewGlobalId"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualString(t, "3,SD5", strings.Join(table.Content[0], ","))
+}
+
+func TestTemplateOutput(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("template"),
+		Template: "{{.GlobalId}} {{.Name}} ({{.UserInfo.Username}})"}
+	ctx.writeResult(docPage())
+	assertEqualString(t, "SD12 PCR run (user1)\n", ctx.Writer.(*bytes.Buffer).String())
+
+	if _, err := parseOutputTemplate("{{.Name"); err == nil {
+		t.Fatalf("Should fail - invalid template")
+	}
+}
+
+func TestColumnsAppliedToCsv(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("csv"), Columns: []string{"id", "tags"}}
+	ctx.writeResult(docPage())
+	assertEqualString(t, "id,tags\n12,\"pcr,mouse\"\n", ctx.Writer.(*bytes.Buffer).String())
+}
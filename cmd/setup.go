@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/richarda23/rspace-client-go/rspace"
 	"github.com/spf13/cobra"
@@ -18,7 +21,7 @@ const (
 )
 
 var (
-	validOutputFormats = []string{"json", "csv", "quiet", "table"}
+	validOutputFormats = []string{"json", "jsonl", "yaml", "csv", "tsv", "quiet", "table", "template", "markdown", "xlsx"}
 	// for listTree
 	validTreeFilters  = []string{"document", "notebook", "folder"}
 	validSortOrders   = []string{"asc", "desc"}
@@ -26,6 +29,9 @@ var (
 	outputFormat      outputFmt
 )
 
+// help text for the --outputFormat flag
+var outputFormatHelp = "Output format: one of '" + strings.Join(validOutputFormats, "', '") + "'"
+
 type outputFmt string
 
 func (ft outputFmt) isJson() bool {
@@ -40,30 +46,138 @@ func (ft outputFmt) isTab() bool {
 func (ft outputFmt) isQuiet() bool {
 	return ft == "quiet"
 }
+func (ft outputFmt) isJsonLines() bool {
+	return ft == "jsonl"
+}
+func (ft outputFmt) isYaml() bool {
+	return ft == "yaml"
+}
+func (ft outputFmt) isTsv() bool {
+	return ft == "tsv"
+}
+func (ft outputFmt) isTemplate() bool {
+	return ft == "template"
+}
+func (ft outputFmt) isMarkdown() bool {
+	return ft == "markdown"
+}
+func (ft outputFmt) isXlsx() bool {
+	return ft == "xlsx"
+}
 
 // Context maintains references to the webClient and result Writers
 type Context struct {
-	WebClient *rspace.RsWebClient
+	WebClient RSpaceClient
 	Writer    io.Writer
 	ErrWriter io.Writer
-	Format    outputFmt
+	// input for confirmation prompts, stdin unless set
+	Reader io.Reader
+	lines  *bufio.Reader
+	Format outputFmt
+	// fields to show in tabular output, if not the default columns
+	Columns []string
+	// Go template applied to each result, for 'template' format
+	Template string
+	// query applied to the JSON form of results before they are formatted
+	Query string
+	// how tables are drawn
+	TableStyle tableStyle
+	// number of items of a batch command that failed
+	ItemFailures int
+	// guards ItemFailures and messages, which may be written from concurrent goroutines
+	errMutex sync.Mutex
 }
 
 func (ctx *Context) messageStdErr(message string) {
-	fmt.Fprintln(ctx.ErrWriter, message)
+	ctx.errMutex.Lock()
+	defer ctx.errMutex.Unlock()
+	fmt.Fprintln(ctx.errWriter(), message)
+}
+
+// errWriter is where messages and errors are written: stderr, unless ErrWriter is set
+func (ctx *Context) errWriter() io.Writer {
+	if ctx.ErrWriter == nil {
+		return os.Stderr
+	}
+	return ctx.ErrWriter
+}
+
+// input reads from stdin, unless Reader is set
+func (ctx *Context) input() *bufio.Reader {
+	if ctx.lines == nil {
+		if ctx.Reader == nil {
+			ctx.Reader = os.Stdin
+		}
+		ctx.lines = bufio.NewReader(ctx.Reader)
+	}
+	return ctx.lines
+}
+
+func (ctx *Context) readLine() string {
+	line, _ := ctx.input().ReadString('\n')
+	return line
+}
+
+func (ctx *Context) readAll() (string, error) {
+	bytes, err := ioutil.ReadAll(ctx.input())
+	return string(bytes), err
+}
+
+// confirm asks a yes/no question, returning true only if the answer is 'y' or 'yes'
+func (ctx *Context) confirm(question string) bool {
+	fmt.Fprintf(ctx.errWriter(), "%s [y/N] ", question)
+	answer := strings.ToLower(strings.TrimSpace(ctx.readLine()))
+	return answer == "y" || answer == "yes"
 }
 
 //Writes the result of a command to one of the supported output formats
-func (ctx *Context) writeResult(formatter ResultListFormatter) {
+func (ctx *Context) writeResult(formatter ResultListFormatter) error {
+	if len(ctx.Query) > 0 {
+		queried, err := newQueryFormatter(formatter, ctx.Query)
+		if err != nil {
+			return err
+		}
+		// single values are written as they are, unless JSON, YAML or a spreadsheet is wanted
+		if queried.isScalar() && !ctx.Format.isJson() && !ctx.Format.isJsonLines() && !ctx.Format.isYaml() && !ctx.Format.isXlsx() {
+			ctx.write(queryValueString(queried.result))
+			return nil
+		}
+		formatter = queried
+	}
 	if ctx.Format.isJson() {
 		ctx.write(formatter.ToJson())
+		return nil
+	} else if ctx.Format.isJsonLines() {
+		return printJsonLines(ctx, formatter)
+	} else if ctx.Format.isYaml() {
+		return printYaml(ctx, formatter.ToJson())
+	} else if ctx.Format.isTemplate() {
+		return printTemplate(ctx, formatter)
 	} else if ctx.Format.isQuiet() {
 		printIds(ctx, formatter.ToQuiet())
-	} else if ctx.Format.isCsv() {
-		printCsv(ctx, formatter.ToTable())
+		return nil
+	}
+	table, err := ctx.toTable(formatter)
+	if err != nil {
+		return err
+	}
+	return ctx.writeTable(table)
+}
+
+// writeTable writes a table in one of the tabular output formats
+func (ctx *Context) writeTable(table *TableResult) error {
+	if ctx.Format.isCsv() {
+		return printCsv(ctx, table)
+	} else if ctx.Format.isTsv() {
+		printTsv(ctx, table)
+	} else if ctx.Format.isMarkdown() {
+		printMarkdown(ctx, table)
+	} else if ctx.Format.isXlsx() {
+		return printXlsx(ctx, table)
 	} else {
-		printTable(ctx, formatter.ToTable())
+		printTable(ctx, table)
 	}
+	return nil
 }
 
 // writes a string to the output stream (either stdout or a defined file)
@@ -72,42 +186,90 @@ func (ctx *Context) write(toWrite string) {
 }
 
 // main initialisation method. Creates an RsWebClient, output writer and format
-func initialiseContextWithTimeout(clientTimeoutSecs int) *Context {
-	_validateFlagArgs()
-	outputFormat = outputFmt(outputFormatArg)
-	rc := Context{}
-	rc.WebClient = initWebClient(clientTimeoutSecs)
-	rc.Writer = initOutputWriter(outFileArg)
-	rc.Format = outputFormat
-	return &rc
+func initialiseContextWithTimeout(clientTimeoutSecs int) (*Context, error) {
+	if err := _validateFlagArgs(); err != nil {
+		return nil, err
+	}
+	webClient, err := initWebClient(clientTimeoutSecs)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := newLocalContext()
+	if err != nil {
+		return nil, err
+	}
+	rc.WebClient = webClient
+	return rc, nil
 }
 
-func initialiseContext() *Context {
+func initialiseContext() (*Context, error) {
 	return initialiseContextWithTimeout(15)
 }
 
-// exits with error if validation fails
-func _validateFlagArgs() {
+// initialises output writer and format only, for commands that don't call the RSpace API
+func initialiseLocalContext() (*Context, error) {
+	if err := _validateFlagArgs(); err != nil {
+		return nil, err
+	}
+	return newLocalContext()
+}
+
+func newLocalContext() (*Context, error) {
+	writer, err := initOutputWriter(outFileArg)
+	if err != nil {
+		return nil, err
+	}
+	rc := Context{}
+	rc.Writer = writer
+	rc.ErrWriter = os.Stderr
+	rc.Format = outputFormat
+	rc.Columns = parseColumns(columnsArg)
+	rc.Template = templateArg
+	rc.Query = queryArg
+	rc.TableStyle = tableStyleFromArgs(rc.Writer)
+	return &rc, nil
+}
+
+// returns an error if any of the common flags are invalid
+func _validateFlagArgs() error {
 	outputFormat = outputFmt(outputFormatArg)
-	validateOutputFormatExit(outputFormat)
-	validateTreeFilterExit(treeFilterArg)
+	if err := validateOutputFormatArg(outputFormat); err != nil {
+		return err
+	}
+	if _, err := validateTreeFilterArg(treeFilterArg); err != nil {
+		return err
+	}
+	if len(queryArg) > 0 {
+		if _, err := compileQuery(queryArg); err != nil {
+			return err
+		}
+	}
+	if outputFormat.isXlsx() && len(outFileArg) == 0 {
+		return newCliError(EXIT_VALIDATION, "Please specify a file to write the workbook to with --outFile when using '-f xlsx'")
+	}
+	if outputFormat.isTemplate() {
+		if _, err := parseOutputTemplate(templateArg); err != nil {
+			return err
+		}
+	}
+	return nil
 }
-func validateTreeFilterExit(treeFilterArg string) []string {
+func validateTreeFilterArg(treeFilterArg string) ([]string, error) {
 	if len(treeFilterArg) == 0 {
-		return make([]string, 0)
+		return make([]string, 0), nil
 	}
 	rc := strings.Split(treeFilterArg, ",")
 
 	if !validateArrayContains(validTreeFilters, rc) {
-		exitWithStdErrMsg("Invalid tree filter, must be comma-separated list of 1 more terms: " + strings.Join(validTreeFilters, ","))
-		return nil
+		return nil, newCliError(EXIT_VALIDATION, "Invalid tree filter, must be comma-separated list of 1 more terms: "+strings.Join(validTreeFilters, ","))
 	}
-	return rc
+	return rc, nil
 }
-func validateOutputFormatExit(toTest outputFmt) {
+func validateOutputFormatArg(toTest outputFmt) error {
 	if !validateOutputFormat(toTest) {
-		exitWithStdErrMsg("Invalid outputFormat argument: must be one of: " + strings.Join(validOutputFormats, ","))
+		return newCliError(EXIT_VALIDATION, "Invalid outputFormat argument: must be one of: "+strings.Join(validOutputFormats, ","))
 	}
+	return nil
 }
 func validateArrayContains(validTerms []string, toTest []string) bool {
 	for _, term := range toTest {
@@ -126,7 +288,7 @@ func validateArrayContains(validTerms []string, toTest []string) bool {
 
 // asserts that an outputFmt argument is valid
 func validateOutputFormat(toTest outputFmt) bool {
-	return toTest.isJson() || toTest.isCsv() || toTest.isQuiet() || toTest.isTab()
+	return validateArrayContains(validOutputFormats, []string{string(toTest)})
 }
 
 // returns an io.Writer for a log file. If logfile is empty, return default writer.
@@ -143,39 +305,40 @@ func initLogWriter(logfile string, defaultWriter io.Writer) io.Writer {
 }
 
 // attempts to open outfile, if set. If is not set, returns std.out writer
-func initOutputWriter(outfile string) io.Writer {
+func initOutputWriter(outfile string) (io.Writer, error) {
 	if len(outfile) == 0 {
-		return os.Stdout
-	} else {
-		file, err := os.Create(outfile)
-		if err != nil {
-			exitWithErr(err)
-		}
-		return file
+		return os.Stdout, nil
+	}
+	file, err := os.Create(outfile)
+	if err != nil {
+		return nil, err
 	}
+	return file, nil
 }
 
 // reads apikey and url from viper configuration,
 // then sets these into an RsWebClient instance
-func initWebClient(clientTimeout int) *rspace.RsWebClient {
+func initWebClient(clientTimeout int) (*rspace.RsWebClient, error) {
 	urlCfg, ok := viper.Get(BASE_URL_ENV_NAME).(string)
 	if !ok || len(urlCfg) == 0 {
-		exitWithStdErrMsg("No URL for RSpace  detected")
+		return nil, newCliError(EXIT_AUTH, "No URL for RSpace  detected")
 	}
 	url, _ := url.Parse(urlCfg)
 	messageStdErr("RSpace URL: " + urlCfg)
 	apikey, ok := viper.Get(APIKEY_ENV_NAME).(string)
 	if !ok || len(apikey) == 0 {
-		exitWithStdErrMsg("No API key detected")
+		return nil, newCliError(EXIT_AUTH, "No API key detected")
 	}
 	messageStdErr("Api key:" + apikey[0:4] + "...")
 	webClient := rspace.NewWebClientCustomTimeout(url, apikey, clientTimeout)
-	return webClient
+	return webClient, nil
 }
 
 // common setup for a paginating command
 func initPaginationFromArgs(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&sortOrderArg, "sortOrder", "", "'asc' or 'desc'")
 	cmd.Flags().StringVar(&orderByArg, "orderBy", "lastModified", "orders results by 'name', 'created' or 'lastModified'")
-	cmd.Flags().IntVar(&pageSizeArg, "maxResults", 20, "Maximum number of results to retrieve")
+	cmd.Flags().IntVar(&pageSizeArg, "maxResults", 20, "Maximum number of results to retrieve, or the page size if --all is set")
+	cmd.Flags().IntVar(&pageNumberArg, "page", 0, "Page number of results to retrieve, starting at 0")
+	cmd.Flags().BoolVar(&allPagesArg, "all", false, "Retrieve all results, fetching every page in turn")
 }
@@ -51,38 +51,41 @@ rspace eln share 12345 --users 122345,45678
 
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		context := initialiseContext()
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(shareArgsa.groupIdsArg) == 0 && len(shareArgsa.userIdsArg) == 0 {
-			exitWithStdErrMsg("You must specify either >= 1 group id (--groups) or >=1  user id (--users) to share with")
+			return newCliError(EXIT_VALIDATION, "You must specify either >= 1 group id (--groups) or >=1  user id (--users) to share with")
 		}
-		doShare(context, args, &shareArgsa)
+		context, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doShare(context, args, &shareArgsa)
 	},
 }
 
-func doShare(ctx *Context, args []string, shareArgs *shareArgs) {
+func doShare(ctx *Context, args []string, shareArgs *shareArgs) error {
 	post := rspace.SharePost{}
-	var ids []int = idsFromGlobalIds(args)
+	var ids []int = ctx.idsFromGlobalIds(args)
 	if len(ids) == 0 {
-		exitWithStdErrMsg("No valid items to share, exiting")
+		return newCliError(EXIT_VALIDATION, "No valid items to share, exiting")
 	}
 
 	post.ItemsToShare = ids
 	var uIds, gIds []int
-	if len(shareArgsa.groupIdsArg) > 0 {
-		groupIdSs := strings.Split(shareArgsa.groupIdsArg, ",")
-		gIds = idsFromGlobalIds(groupIdSs)
+	if len(shareArgs.groupIdsArg) > 0 {
+		groupIdSs := strings.Split(shareArgs.groupIdsArg, ",")
+		gIds = ctx.idsFromGlobalIds(groupIdSs)
 	}
-	if len(shareArgsa.userIdsArg) > 0 {
-		userIdSs := strings.Split(shareArgsa.userIdsArg, ",")
-		uIds = idsFromGlobalIds(userIdSs)
+	if len(shareArgs.userIdsArg) > 0 {
+		userIdSs := strings.Split(shareArgs.userIdsArg, ",")
+		uIds = ctx.idsFromGlobalIds(userIdSs)
 	}
 	if len(uIds) == 0 && len(gIds) == 0 {
-		exitWithStdErrMsg("No valid user or group Ids to share with")
+		return newCliError(EXIT_VALIDATION, "No valid user or group Ids to share with")
 	}
-	perm := shareArgsa.permArg
+	perm := shareArgs.permArg
 	if ok := validateArrayContains([]string{"read", "edit"}, []string{perm}); !ok {
-		exitWithStdErrMsg(fmt.Sprintf("%s is not a valid permission", perm))
+		return newCliError(EXIT_VALIDATION, fmt.Sprintf("%s is not a valid permission", perm))
 	}
 	//TODO remove info logging from client, support target folder, don't send 0 as a value
 	uPosts := make([]rspace.UserShare, 0)
@@ -100,16 +103,19 @@ func doShare(ctx *Context, args []string, shareArgs *shareArgs) {
 		}
 	}
 	if len(gPosts) == 1 {
-		gPosts[0].SharedFolderId = shareArgsa.targetFolderArg
+		gPosts[0].SharedFolderId = shareArgs.targetFolderArg
 	}
 	post.Groups = gPosts
 	var err error
 	shares, err := ctx.WebClient.Share(&post)
 	if err != nil {
-		exitWithErr(err)
+		return err
 	}
 	formatter := &ShareInfoListFormatter{shares}
-	ctx.writeResult(formatter)
+	if err := ctx.writeResult(formatter); err != nil {
+		return err
+	}
+	return ctx.itemFailuresError()
 }
 
 type ShareInfoListFormatter struct {
@@ -120,6 +126,14 @@ func (fs *ShareInfoListFormatter) ToJson() string {
 	return prettyMarshal(fs.shareList)
 }
 
+func (fs *ShareInfoListFormatter) items() []interface{} {
+	rc := make([]interface{}, 0)
+	for _, share := range fs.shareList.ShareInfos {
+		rc = append(rc, share)
+	}
+	return rc
+}
+
 func (ds *ShareInfoListFormatter) ToQuiet() []identifiable {
 	rows := make([]identifiable, 0)
 	for _, res := range ds.shareList.ShareInfos {
@@ -37,7 +37,10 @@ type StatusCli interface {
 
 //fixed signature for cobra framework
 func runFunction(cmd *cobra.Command, args []string) error {
-	context := initialiseContext()
+	context, err := initialiseContext()
+	if err != nil {
+		return err
+	}
 	return doRun(context.WebClient, context)
 }
 
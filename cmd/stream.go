@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"strings"
+)
+
+// number of table rows buffered to fit column widths before the table is printed
+const TABLE_LOOKAHEAD_ROWS = 200
+
+// StreamingFormatter formats one page of a listing. Pages can be combined
+// when the whole result is needed at once, e.g. for JSON output.
+type StreamingFormatter interface {
+	ResultListFormatter
+	appendPage(next StreamingFormatter)
+}
+
+// resultStream writes a listing a page at a time, so that long listings
+// needn't be held in memory. CSV, TSV, Markdown, XLSX, JSON Lines, template and quiet output is written as each page arrives.
+// Table rows are buffered until TABLE_LOOKAHEAD_ROWS are available to fit column widths to;
+// later rows are abbreviated to fit. JSON and YAML output, and the results of a --jmespath query,
+// are written once all pages are read.
+type resultStream struct {
+	ctx       *Context
+	lookAhead int
+	first     StreamingFormatter
+	csvWriter *csv.Writer
+	// true once headers are written, for TSV and Markdown
+	wroteHeader bool
+	xlsx        *xlsxWriter
+	headers     []columnDef
+	buffered    [][]string
+	renderer    *tableRenderer
+}
+
+func (ctx *Context) newResultStream() *resultStream {
+	return &resultStream{ctx: ctx, lookAhead: TABLE_LOOKAHEAD_ROWS, buffered: make([][]string, 0)}
+}
+
+func (s *resultStream) writePage(page StreamingFormatter) error {
+	wholeResult := s.ctx.Format.isJson() || s.ctx.Format.isYaml() || len(s.ctx.Query) > 0
+	if s.first == nil {
+		s.first = page
+	} else if wholeResult {
+		s.first.appendPage(page)
+	}
+	if wholeResult {
+		return nil
+	} else if s.ctx.Format.isJsonLines() {
+		return printJsonLines(s.ctx, page)
+	} else if s.ctx.Format.isTemplate() {
+		return printTemplate(s.ctx, page)
+	} else if s.ctx.Format.isQuiet() {
+		printIds(s.ctx, page.ToQuiet())
+		return nil
+	}
+	table, err := s.ctx.toTable(page)
+	if err != nil || len(table.Content) == 0 {
+		return err
+	}
+	if s.ctx.Format.isCsv() {
+		return s.writeCsv(table)
+	} else if s.ctx.Format.isTsv() {
+		s.writeTsv(table)
+	} else if s.ctx.Format.isMarkdown() {
+		s.writeMarkdown(table)
+	} else if s.ctx.Format.isXlsx() {
+		return s.writeXlsx(table)
+	} else {
+		s.writeTable(table)
+	}
+	return nil
+}
+
+// close writes any remaining output. If there were no results, headers are still written
+func (s *resultStream) close() error {
+	if s.first == nil {
+		return nil
+	}
+	if len(s.ctx.Query) > 0 {
+		return s.ctx.writeResult(s.first)
+	} else if s.ctx.Format.isJson() {
+		s.ctx.write(s.first.ToJson())
+		return nil
+	} else if s.ctx.Format.isYaml() {
+		return printYaml(s.ctx, s.first.ToJson())
+	} else if s.ctx.Format.isQuiet() || s.ctx.Format.isJsonLines() || s.ctx.Format.isTemplate() {
+		return nil
+	} else if s.ctx.Format.isCsv() {
+		if s.csvWriter == nil {
+			return s.writeEmptyTable()
+		}
+	} else if s.ctx.Format.isTsv() || s.ctx.Format.isMarkdown() {
+		if !s.wroteHeader {
+			return s.writeEmptyTable()
+		}
+	} else if s.ctx.Format.isXlsx() {
+		if s.xlsx == nil {
+			return s.writeEmptyTable()
+		}
+		return s.xlsx.close()
+	} else if s.headers == nil {
+		return s.writeEmptyTable()
+	} else {
+		if s.renderer == nil {
+			s.flushTable()
+		}
+		s.renderer.close()
+	}
+	return nil
+}
+
+// writeEmptyTable writes the headers of a listing with no results
+func (s *resultStream) writeEmptyTable() error {
+	table, err := s.ctx.toTable(s.first)
+	if err != nil {
+		return err
+	}
+	return s.ctx.writeTable(table)
+}
+
+func (s *resultStream) writeCsv(table *TableResult) error {
+	if s.csvWriter == nil {
+		s.csvWriter = csv.NewWriter(s.ctx.Writer)
+		s.csvWriter.Write(columnDefsToString(table.Headers))
+	}
+	return s.csvWriter.WriteAll(table.Content)
+}
+
+func (s *resultStream) writeTsv(table *TableResult) {
+	if !s.wroteHeader {
+		s.ctx.write(strings.Join(columnDefsToString(table.Headers), "\t"))
+		s.wroteHeader = true
+	}
+	for _, row := range tsvRows(table) {
+		s.ctx.write(row)
+	}
+}
+
+func (s *resultStream) writeMarkdown(table *TableResult) {
+	if !s.wroteHeader {
+		printMarkdownHeaders(s.ctx, table.Headers)
+		s.wroteHeader = true
+	}
+	printMarkdownRows(s.ctx, table.Content)
+}
+
+func (s *resultStream) writeXlsx(table *TableResult) error {
+	var err error
+	if s.xlsx == nil {
+		if s.xlsx, err = newXlsxWriter(s.ctx.Writer, "Results", table.Headers); err == nil {
+			err = s.xlsx.writeHeaders(table.Headers)
+		}
+	}
+	if err == nil {
+		err = s.xlsx.writeRows(table.Content)
+	}
+	return err
+}
+
+func (s *resultStream) writeTable(table *TableResult) {
+	if s.renderer != nil {
+		s.renderer.writeRows(table.Content)
+		return
+	}
+	s.headers = widestColumns(s.headers, table.Headers)
+	s.buffered = append(s.buffered, table.Content...)
+	if len(s.buffered) >= s.lookAhead {
+		s.flushTable()
+	}
+}
+
+func (s *resultStream) flushTable() {
+	s.renderer = newTableRenderer(s.ctx, s.headers)
+	s.renderer.writeHeaders(s.headers)
+	s.renderer.writeRows(s.buffered)
+	s.buffered = nil
+}
+
+// widestColumns returns the headers with the larger of each column's widths
+func widestColumns(current []columnDef, next []columnDef) []columnDef {
+	if current == nil {
+		return append([]columnDef{}, next...)
+	}
+	for i := range current {
+		if i < len(next) && next[i].Width > current[i].Width {
+			current[i].Width = next[i].Width
+		}
+	}
+	return current
+}
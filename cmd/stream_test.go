@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+func formPage(totalHits int, names ...string) *FormListFormatter {
+	forms := make([]rspace.Form, 0)
+	for i, name := range names {
+		namable := &rspace.IdentifiableNamable{Id: i + 1, GlobalId: "FM1", Name: name}
+		forms = append(forms, rspace.Form{IdentifiableNamable: namable})
+	}
+	return &FormListFormatter{&rspace.FormList{Forms: forms, TotalHits: totalHits}}
+}
+
+func streamPages(format string, lookAhead int, pages ...StreamingFormatter) string {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt(format)}
+	stream := ctx.newResultStream()
+	stream.lookAhead = lookAhead
+	for _, page := range pages {
+		stream.writePage(page)
+	}
+	stream.close()
+	return ctx.Writer.(*bytes.Buffer).String()
+}
+
+func TestStreamCsvWritesHeadersOnce(t *testing.T) {
+	out := streamPages("csv", 10, formPage(3, "a", "b"), formPage(3, "c"))
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "Id,GlobalId,Name") {
+		t.Fatalf("Expected header and 3 rows but got %s", out)
+	}
+}
+
+func TestStreamQuiet(t *testing.T) {
+	out := streamPages("quiet", 10, formPage(3, "a", "b"), formPage(3, "c"))
+	assertEqualString(t, "1\n2\n1\n", out)
+}
+
+func TestStreamJsonCombinesPages(t *testing.T) {
+	out := streamPages("json", 10, formPage(3, "a", "b"), formPage(3, "c"))
+	combined := rspace.FormList{}
+	if err := json.Unmarshal([]byte(out), &combined); err != nil {
+		t.Fatal(err)
+	}
+	if len(combined.Forms) != 3 || combined.TotalHits != 3 {
+		t.Fatalf("Expected 3 forms in JSON but got %d", len(combined.Forms))
+	}
+}
+
+func TestStreamTableLookAhead(t *testing.T) {
+	out := streamPages("table", 2, formPage(3, "a", "b"), formPage(3, "c"))
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "Id") {
+		t.Fatalf("Expected header and 3 rows but got %s", out)
+	}
+	// all rows printed with the same column widths
+	if len(lines[1]) != len(lines[3]) {
+		t.Fatalf("Rows after look-ahead should have same widths: %q %q", lines[1], lines[3])
+	}
+}
+
+func TestStreamEmptyResultsWritesHeaders(t *testing.T) {
+	out := streamPages("csv", 10, formPage(0))
+	assertEqualString(t, "Id,GlobalId,Name,StableId,PublishingState\n", out)
+}
+
+func TestWidestColumns(t *testing.T) {
+	headers := widestColumns(nil, []columnDef{columnDef{"Name", 5}, columnDef{"Id", 8}})
+	headers = widestColumns(headers, []columnDef{columnDef{"Name", 12}, columnDef{"Id", 4}})
+	if headers[0].Width != 12 || headers[1].Width != 8 {
+		t.Fatalf("Unexpected widths %v", headers)
+	}
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// narrowest a column is shrunk to when fitting a table to the terminal
+const MIN_FITTED_COLUMN_WIDTH = 6
+
+const (
+	ansiBold  = "\x1b[1m"
+	ansiReset = "\x1b[0m"
+)
+
+// tableStyle controls how tables are drawn
+type tableStyle struct {
+	// total width to fit the table into, or 0 to never shrink columns
+	Width int
+	// wrap long cells onto several lines, rather than abbreviating them
+	Wrap    bool
+	Borders bool
+	Colour  bool
+}
+
+// tableStyleFromArgs detects the terminal width, if writing to a terminal
+func tableStyleFromArgs(writer io.Writer) tableStyle {
+	isTerminal := isTerminalWriter(writer)
+	style := tableStyle{Wrap: wrapArg, Borders: bordersArg}
+	if isTerminal {
+		style.Width = terminalWidth(writer.(*os.File))
+	}
+	switch colourArg {
+	case "always":
+		style.Colour = true
+	case "auto":
+		style.Colour = isTerminal && len(os.Getenv("NO_COLOR")) == 0
+	}
+	return style
+}
+
+func isTerminalWriter(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth asks the terminal for its width, falling back to the COLUMNS environment variable
+func terminalWidth(file *os.File) int {
+	if width := terminalWidthFromOS(file); width > 0 {
+		return width
+	}
+	width, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return width
+}
+
+// wide (e.g. CJK and emoji) characters take up 2 columns in a terminal
+var wideRuneRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x2E80, 0x303E}, {0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE30, 0xFE4F}, {0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6}, {0x1F300, 0x1F64F}, {0x1F900, 0x1F9FF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+func runeWidth(r rune) int {
+	if r < 0x20 || (r >= 0x7F && r < 0xA0) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range wideRuneRanges {
+		if r >= wide[0] && r <= wide[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// displayWidth is the number of terminal columns needed to show s
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// truncateToWidth returns the longest prefix of s that fits in width columns,
+// never splitting a character
+func truncateToWidth(s string, width int) string {
+	used := 0
+	for i, r := range s {
+		if used+runeWidth(r) > width {
+			return s[:i]
+		}
+		used += runeWidth(r)
+	}
+	return s
+}
+
+// padRight pads s with spaces to width columns
+func padRight(s string, width int) string {
+	if gap := width - displayWidth(s); gap > 0 {
+		return s + strings.Repeat(" ", gap)
+	}
+	return s
+}
+
+// wrapCell splits s into lines no wider than width, breaking at spaces where possible
+func wrapCell(s string, width int) []string {
+	if width < 1 || displayWidth(s) <= width {
+		return []string{s}
+	}
+	lines := make([]string, 0)
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for displayWidth(word) > width {
+			// a long word is broken where it reaches the end of the line
+			head := truncateToWidth(word, width-displayWidth(line)-spaceIfNotEmpty(line))
+			if len(head) == 0 && len(line) > 0 {
+				lines = append(lines, line)
+				line = ""
+				continue
+			} else if len(head) == 0 {
+				// a character wider than the column
+				_, size := utf8.DecodeRuneInString(word)
+				head = word[:size]
+			}
+			lines = append(lines, joinWithSpace(line, head))
+			line = ""
+			word = word[len(head):]
+		}
+		if displayWidth(line)+spaceIfNotEmpty(line)+displayWidth(word) > width {
+			lines = append(lines, line)
+			line = word
+		} else {
+			line = joinWithSpace(line, word)
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func spaceIfNotEmpty(s string) int {
+	if len(s) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func joinWithSpace(a, b string) string {
+	if len(a) == 0 {
+		return b
+	}
+	return a + " " + b
+}
+
+// tableRenderer writes rows of a table with fixed column widths, which are
+// shrunk if needed to fit the table style's width
+type tableRenderer struct {
+	ctx    *Context
+	style  tableStyle
+	widths []int
+}
+
+func newTableRenderer(ctx *Context, headers []columnDef) *tableRenderer {
+	widths := make([]int, 0)
+	for _, header := range headers {
+		widths = append(widths, header.Width)
+	}
+	r := &tableRenderer{ctx: ctx, style: ctx.TableStyle, widths: widths}
+	r.fit()
+	return r
+}
+
+// renderedWidth is the width of a table row. Columns are separated by tabs, unless bordered.
+func (r *tableRenderer) renderedWidth() int {
+	total := 0
+	if r.style.Borders {
+		total = 1
+		for _, w := range r.widths {
+			total += w + 3
+		}
+		return total
+	}
+	for i, w := range r.widths {
+		total += w
+		if i < len(r.widths)-1 {
+			total = (total/8 + 1) * 8
+		}
+	}
+	return total
+}
+
+// fit shrinks the widest column until the table fits, or no column can shrink further
+func (r *tableRenderer) fit() {
+	if r.style.Width <= 0 {
+		return
+	}
+	for r.renderedWidth() > r.style.Width {
+		widest := -1
+		for i, w := range r.widths {
+			if w > MIN_FITTED_COLUMN_WIDTH && (widest < 0 || w > r.widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return
+		}
+		r.widths[widest]--
+	}
+}
+
+func (r *tableRenderer) writeHeaders(headers []columnDef) {
+	if r.style.Borders {
+		r.writeBorder()
+	}
+	titles := make([]string, 0)
+	for _, header := range headers {
+		titles = append(titles, header.Title)
+	}
+	r.writeRow(titles, r.style.Colour)
+	if r.style.Borders {
+		r.writeBorder()
+	}
+}
+
+func (r *tableRenderer) writeRows(rows [][]string) {
+	for _, row := range rows {
+		r.writeRow(row, false)
+	}
+}
+
+// close finishes the table
+func (r *tableRenderer) close() {
+	if r.style.Borders {
+		r.writeBorder()
+	}
+}
+
+func (r *tableRenderer) writeBorder() {
+	parts := make([]string, 0)
+	for _, w := range r.widths {
+		parts = append(parts, strings.Repeat("-", w+2))
+	}
+	r.ctx.write("+" + strings.Join(parts, "+") + "+")
+}
+
+// writeRow writes a row as one line, or several lines if cells are wrapped
+func (r *tableRenderer) writeRow(row []string, bold bool) {
+	cellLines := make([][]string, len(r.widths))
+	height := 1
+	for i := range r.widths {
+		cell := ""
+		if i < len(row) {
+			cell = validUTF8(row[i])
+		}
+		if r.style.Wrap {
+			cellLines[i] = wrapCell(cell, r.widths[i])
+		} else {
+			cellLines[i] = []string{abbreviate(cell, r.widths[i])}
+		}
+		if len(cellLines[i]) > height {
+			height = len(cellLines[i])
+		}
+	}
+	for line := 0; line < height; line++ {
+		toPrint := make([]string, 0)
+		for i, lines := range cellLines {
+			text := ""
+			if line < len(lines) {
+				text = lines[line]
+			}
+			text = padRight(text, r.widths[i])
+			if bold {
+				text = ansiBold + text + ansiReset
+			}
+			toPrint = append(toPrint, text)
+		}
+		if r.style.Borders {
+			r.ctx.write("| " + strings.Join(toPrint, " | ") + " |")
+		} else {
+			r.ctx.write(strings.Join(toPrint, "\t"))
+		}
+	}
+}
+
+// validUTF8 replaces invalid byte sequences, so that cells can always be measured
+func validUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return strings.ToValidUTF8(s, "�")
+}
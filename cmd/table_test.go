@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	assertIdEquals(t, displayWidth("MyForm- Ɣ"), 9)
+	assertIdEquals(t, displayWidth("实验记录"), 8)
+	// combining acute accent takes no space
+	assertIdEquals(t, displayWidth("é"), 1)
+}
+
+func TestAbbreviateMultiByte(t *testing.T) {
+	abbreviated := abbreviate("ƔƔƔƔƔƔƔƔ", 6)
+	assertEqualString(t, "ƔƔƔƔ..", abbreviated)
+	// wide characters are never split
+	abbreviated = abbreviate("实验记录实验", 7)
+	assertEqualString(t, "实验..", abbreviated)
+	if !utf8.ValidString(abbreviated) {
+		t.Fatalf("Abbreviation split a character")
+	}
+}
+
+func TestWrapCell(t *testing.T) {
+	lines := wrapCell("a quick brown fox", 7)
+	assertEqualString(t, "a quick|brown|fox", strings.Join(lines, "|"))
+	lines = wrapCell("abcdefghij", 4)
+	assertEqualString(t, "abcd|efgh|ij", strings.Join(lines, "|"))
+	lines = wrapCell("实验记录", 3)
+	assertEqualString(t, "实|验|记|录", strings.Join(lines, "|"))
+}
+
+func renderTestTable(style tableStyle) []string {
+	ctx := &Context{Writer: bytes.NewBufferString(""), TableStyle: style}
+	table := &TableResult{[]columnDef{columnDef{"Id", 4}, columnDef{"Name", 20}},
+		[][]string{[]string{"1", "Ɣ-form with a long name"}, []string{"2", "short"}}}
+	printTable(ctx, table)
+	return strings.Split(strings.TrimSuffix(ctx.Writer.(*bytes.Buffer).String(), "\n"), "\n")
+}
+
+func TestTableAlignsMultiByteNames(t *testing.T) {
+	lines := renderTestTable(tableStyle{})
+	if len(lines) != 3 || displayWidth(lines[1]) != displayWidth(lines[2]) {
+		t.Fatalf("Rows should be the same width: %q", lines)
+	}
+	assertEqualString(t, "1   \tƔ-form with a long..", lines[1])
+}
+
+func TestTableFitsWidthAndWraps(t *testing.T) {
+	lines := renderTestTable(tableStyle{Width: 20, Wrap: true})
+	for _, line := range lines {
+		if width := displayWidth(strings.Replace(line, "\t", "    ", 1)); width > 20 {
+			t.Fatalf("Line %q wider than 20 columns", line)
+		}
+	}
+	if len(lines) < 4 {
+		t.Fatalf("Expected long name to be wrapped: %q", lines)
+	}
+}
+
+func TestTableBordersAndColour(t *testing.T) {
+	lines := renderTestTable(tableStyle{Borders: true, Colour: true})
+	if len(lines) != 6 || lines[0] != lines[2] || lines[0] != lines[5] {
+		t.Fatalf("Expected table with borders: %q", lines)
+	}
+	assertEqualString(t, "+------+----------------------+", lines[0])
+	if !strings.Contains(lines[1], ansiBold+"Id  "+ansiReset) {
+		t.Fatalf("Expected bold headers: %q", lines[1])
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package cmd
+
+import (
+	"os"
+)
+
+// terminalWidthFromOS returns 0 on Windows, so COLUMNS is used if set
+func terminalWidthFromOS(file *os.File) int {
+	return 0
+}
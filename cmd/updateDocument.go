@@ -0,0 +1,136 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+	"github.com/spf13/cobra"
+)
+
+type updateDocArgs struct {
+	Name        string
+	Tags        string
+	Field       int
+	Content     string
+	ContentFile string
+}
+
+var updateDocArgV = updateDocArgs{}
+
+var updateDocumentCmd = &cobra.Command{
+	Use:   "updateDocument",
+	Short: "Updates a document's name, tags or the content of a field",
+	Long: `Update the name or tags of a document, or replace the content of one of its fields.
+
+Fields are numbered from 1, in the order shown by 'getDocument'. The field can be omitted
+for a Basic document, which has only 1 field.
+As for 'addDocument', HTML files are loaded verbatim, and other files are wrapped in 'pre' tags.
+Tags replace any existing tags.
+	`,
+	Aliases: []string{"editDocument", "docUpdate"},
+	Example: `
+// rename a document
+rspace eln updateDocument SD123 --name "PCR run 2"
+
+// replace tags
+rspace eln updateDocument SD123 --tags pcr,2021
+
+// replace the content of the 3rd field with the content of a file
+rspace eln updateDocument SD123 --field 3 --file results.html
+
+// replace the content of a Basic document
+rspace eln updateDocument SD123 --content "<p>Repeated with fresh samples</p>"
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return doUpdateDocument(ctx, ctx.WebClient, args[0], updateDocArgV)
+	},
+}
+
+// UpdateDocClient edits documents, uploading images in their new content
+type UpdateDocClient interface {
+	DocEditClient
+	FileUploadClient
+}
+
+func doUpdateDocument(ctx *Context, docClient UpdateDocClient, idArg string, args updateDocArgs) error {
+	id, err := documentIdFromArg(idArg)
+	if err != nil {
+		return err
+	}
+	hasContent := len(args.Content) > 0 || len(args.ContentFile) > 0
+	if len(args.Name) == 0 && len(args.Tags) == 0 && !hasContent {
+		return newCliError(EXIT_VALIDATION, "Please specify a new name, tags, or field content with --content or --file")
+	}
+	if len(args.Content) > 0 && len(args.ContentFile) > 0 {
+		return newCliError(EXIT_VALIDATION, "Please use either --content or --file, not both")
+	}
+	if args.Field != 0 && !hasContent {
+		return newCliError(EXIT_VALIDATION, "Please specify the new field content with --content or --file")
+	}
+	post := &rspace.DocumentPost{Name: args.Name, Tags: args.Tags}
+	if hasContent {
+		doc, err := docClient.DocumentById(id)
+		if err != nil {
+			return err
+		}
+		field, err := fieldToUpdate(doc, args.Field)
+		if err != nil {
+			return err
+		}
+		content, err := getContent(ctx, docClient, addDocArgs{Content: args.Content, ContentFile: args.ContentFile})
+		if err != nil {
+			return err
+		}
+		post.Fields = []rspace.FieldContent{rspace.FieldContent{Id: field.Id, Content: content}}
+	}
+	updated, err := docClient.DocumentEdit(id, post)
+	if err != nil {
+		return err
+	}
+	return ctx.writeResult(&DocumentFormatter{updated})
+}
+
+// fieldToUpdate finds a field by its number, counting from 1. If not set, the document must have a single field.
+func fieldToUpdate(doc *rspace.Document, fieldNumber int) (*rspace.Field, error) {
+	if fieldNumber == 0 {
+		if len(doc.Fields) != 1 {
+			return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("%s has %d fields, please choose one with --field",
+				doc.GlobalId, len(doc.Fields)))
+		}
+		fieldNumber = 1
+	}
+	if fieldNumber < 1 || fieldNumber > len(doc.Fields) {
+		return nil, newCliError(EXIT_VALIDATION, fmt.Sprintf("Field must be between 1 and %d, but was %d",
+			len(doc.Fields), fieldNumber))
+	}
+	return &doc.Fields[fieldNumber-1], nil
+}
+
+func init() {
+	elnCmd.AddCommand(updateDocumentCmd)
+	updateDocumentCmd.Flags().StringVar(&updateDocArgV.Name, "name", "", "A new name for the document")
+	updateDocumentCmd.Flags().StringVar(&updateDocArgV.Tags, "tags", "", "One or more tags, comma separated, replacing existing tags")
+	updateDocumentCmd.Flags().IntVar(&updateDocArgV.Field, "field", 0, "Number of the field to update, starting from 1")
+	updateDocumentCmd.Flags().StringVar(&updateDocArgV.Content, "content", "", "Text or HTML content for the field")
+	updateDocumentCmd.Flags().StringVar(&updateDocArgV.ContentFile, "file", "", "A file of text or HTML content for the field")
+}
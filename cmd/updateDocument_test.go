@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/richarda23/rspace-client-go/rspace"
+)
+
+func newStructuredDoc(client *memoryRSpace) *rspace.Document {
+	form := client.addForm("Experiment", []rspace.FormField{memoryFormField("Objective", "Text"),
+		memoryFormField("Temperature", "Number")})
+	doc, _ := client.NewDocumentWithContent(&rspace.DocumentPost{Name: "run1", Tags: "pcr",
+		FormID: rspace.FormId{Id: form.Id}, Fields: []rspace.FieldContent{{Content: "amplify"}, {Content: "72"}}})
+	return doc
+}
+
+func TestGetDocumentShowsFieldsAsRows(t *testing.T) {
+	ctx, client, out, _ := newMemoryContext("csv")
+	doc := newStructuredDoc(client)
+	if err := doGetDocument(ctx, client, doc.GlobalId); err != nil {
+		t.Fatalf("Expected document but got %v", err)
+	}
+	for _, expected := range []string{"Tags,pcr", "Form,Experiment (FM", "Owner,user1", "1: Objective (Text),amplify", "2: Temperature (Number),72"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected '%s' in output but got %s", expected, out.String())
+		}
+	}
+	err := doGetDocument(ctx, client, "SD99999")
+	if exitCodeFor(err) != EXIT_NOT_FOUND {
+		t.Errorf("Expected not found but got %v", err)
+	}
+}
+
+func TestUpdateDocumentField(t *testing.T) {
+	ctx, client, out, _ := newMemoryContext("json")
+	doc := newStructuredDoc(client)
+	err := doUpdateDocument(ctx, client, doc.GlobalId, updateDocArgs{Name: "run2", Field: 2, Content: "95"})
+	if err != nil {
+		t.Fatalf("Expected document to be updated but got %v", err)
+	}
+	updated := rspace.Document{}
+	json.Unmarshal(out.Bytes(), &updated)
+	if updated.Name != "run2" || updated.Tags != "pcr" || updated.Fields[0].Content != "amplify" || updated.Fields[1].Content != "95" {
+		t.Errorf("Expected name and 2nd field to be updated but got %+v", updated)
+	}
+}
+
+func TestUpdateDocumentValidation(t *testing.T) {
+	ctx, client, _, _ := newMemoryContext("json")
+	doc := newStructuredDoc(client)
+	invalid := []updateDocArgs{
+		updateDocArgs{},
+		updateDocArgs{Field: 1},
+		updateDocArgs{Field: 3, Content: "x"},
+		// a field must be chosen as there are 2
+		updateDocArgs{Content: "x"},
+		updateDocArgs{Content: "x", ContentFile: "testData/textContent.txt"},
+	}
+	for _, args := range invalid {
+		if err := doUpdateDocument(ctx, client, doc.GlobalId, args); exitCodeFor(err) != EXIT_VALIDATION {
+			t.Errorf("Expected validation error for %+v but got %v", args, err)
+		}
+	}
+	basic, _ := client.NewBasicDocumentWithContent("basic", "", "old")
+	if err := doUpdateDocument(ctx, client, basic.GlobalId, updateDocArgs{ContentFile: "testData/textContent.txt"}); err != nil {
+		t.Fatalf("Expected the only field to be updated but got %v", err)
+	}
+	if content := fieldContent(client, basic); !strings.HasPrefix(content, "<pre>") {
+		t.Errorf("Expected text file content wrapped in 'pre' but got %s", content)
+	}
+}
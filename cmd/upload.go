@@ -16,7 +16,6 @@ limitations under the License.
 package cmd
 
 import (
-	"bytes"
 	"fmt"
 	"html/template"
 	"io/ioutil"
@@ -118,15 +117,20 @@ rspace eln upload folderWithManyFiles --recursive --logfile progress.txt
 rspace eln upload file.doc imageFolder --recursive --add-summary --caption anti-CDC2-immunofluorescence
 	`,
 	Args: cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := initialiseContext()
-		uploadArgs(ctx, args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := initialiseContext()
+		if err != nil {
+			return err
+		}
+		return uploadArgs(ctx, args)
 	},
 }
 
-func uploadArgs(ctx *Context, args []string) {
+func uploadArgs(ctx *Context, args []string) error {
 	// fail fast if files can't be read
-	validateInputFilePaths(args)
+	if err := validateInputFilePaths(args); err != nil {
+		return err
+	}
 	filesToUpload := scanFiles(args, uploadArgsArg.RecursiveFlag, acceptAll())
 
 	messageStdErr(fmt.Sprintf("Found %d files to upload - total amount to upload is %s", len(filesToUpload),
@@ -138,13 +142,16 @@ func uploadArgs(ctx *Context, args []string) {
 			uploadedFiles = append(uploadedFiles, fileInfo)
 		}
 	}
-	report(ctx, uploadedFiles)
+	if err := report(ctx, uploadedFiles); err != nil {
+		return err
+	}
+	return ctx.itemFailuresError()
 }
 
-func report(ctx *Context, uploaded []*rspace.FileInfo) {
+func report(ctx *Context, uploaded []*rspace.FileInfo) error {
 	if uploadArgsArg.DryrunFlag {
 		messageStdErr(fmt.Sprintf("File upload would upload %d files", len(uploaded)))
-		return
+		return nil
 	}
 	if uploadArgsArg.GenerateSummaryDoc {
 		addSummaryDoc(ctx, uploaded)
@@ -153,11 +160,15 @@ func report(ctx *Context, uploaded []*rspace.FileInfo) {
 
 	var fal FileArrayList = FileArrayList{uploaded}
 	var formatter FileListFormatter = FileListFormatter{fal}
-	ctx.writeResult(&formatter)
+	return ctx.writeResult(&formatter)
 }
 
 func addSummaryDoc(ctx *Context, uploaded []*rspace.FileInfo) {
-	contentStr, _ := generateSummaryContent(uploaded)
+	contentStr, err := generateSummaryContent(uploaded)
+	if err != nil {
+		messageStdErr(err.Error())
+		return
+	}
 	messageStdErr(contentStr)
 	summaryDocInfo, err := ctx.WebClient.NewBasicDocumentWithContent("fileupload-summary", "", contentStr)
 	if err != nil {
@@ -211,10 +222,7 @@ func generateSummaryContent(results2 []*rspace.FileInfo) (string, error) {
 		}
 		templToUse = string(bytes)
 	}
-	t := template.Must(template.New("tmpl").Parse(templToUse))
-	var buf bytes.Buffer
-	t.Execute(&buf, results)
-	return buf.String(), nil
+	return executeHtmlTemplate("tmpl", templToUse, results)
 }
 
 func fileListToBaseInfoList(results []*rspace.FileInfo) []rspace.BasicInfo {
@@ -238,7 +246,8 @@ func postFile(ctx *Context, fileInfo *scannedFileInfo) *rspace.FileInfo {
 	file, err := ctx.WebClient.UploadFile(cfg)
 	if err != nil {
 		// other files might upload OK, so don't exit here
-		messageStdErr(err.Error())
+		ctx.reportItemFailure(filePath, err)
+		return nil
 	}
 	fileInfo.Uploaded = true
 	return file
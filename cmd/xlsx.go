@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Workbooks are written a row at a time to a single sheet. Cells that look like numbers or
+// dates are stored as such, so they can be sorted and summed in Excel; everything else is
+// stored as text.
+
+// numbers with leading zeros, e.g. '007', are kept as text
+var xlsxNumberRegexp = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// date-times are as shown in tables (minutes), or RFC3339
+var xlsxDateTimeLayouts = []string{"2006-01-02T15:04", time.RFC3339, "2006-01-02T15:04:05.000Z", "2006-01-02 15:04"}
+
+const (
+	xlsxDateTimeFormat = "yyyy-mm-dd hh:mm"
+	xlsxDateFormat     = "yyyy-mm-dd"
+)
+
+type xlsxWriter struct {
+	out           io.Writer
+	file          *excelize.File
+	sheet         *excelize.StreamWriter
+	headerStyle   int
+	dateTimeStyle int
+	dateStyle     int
+	row           int
+}
+
+// newXlsxWriter creates a workbook with a single sheet, with columns sized from headers
+func newXlsxWriter(w io.Writer, sheetName string, headers []columnDef) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	x := &xlsxWriter{out: w, file: f}
+	err := f.SetSheetName(f.GetSheetName(0), sheetName)
+	if err == nil {
+		x.sheet, err = f.NewStreamWriter(sheetName)
+	}
+	for i, header := range headers {
+		if err == nil {
+			width := math.Max(float64(header.Width), float64(displayWidth(header.Title))) + 2
+			err = x.sheet.SetColWidth(i+1, i+1, math.Min(width, 255))
+		}
+	}
+	if err == nil {
+		x.headerStyle, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	}
+	if err == nil {
+		dateTimeFormat := xlsxDateTimeFormat
+		x.dateTimeStyle, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateTimeFormat})
+	}
+	if err == nil {
+		dateFormat := xlsxDateFormat
+		x.dateStyle, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsxWriter) writeHeaders(headers []columnDef) error {
+	return x.writeRow(columnDefsToString(headers), true)
+}
+
+func (x *xlsxWriter) writeRow(cells []string, isHeader bool) error {
+	x.row++
+	values := make([]interface{}, len(cells))
+	for i, cell := range cells {
+		if isHeader {
+			values[i] = excelize.Cell{StyleID: x.headerStyle, Value: cell}
+		} else if t, isDateTime, ok := xlsxDateValue(cell); ok {
+			style := x.dateStyle
+			if isDateTime {
+				style = x.dateTimeStyle
+			}
+			values[i] = excelize.Cell{StyleID: style, Value: t}
+		} else if xlsxNumberRegexp.MatchString(cell) && len(cell) < 16 {
+			values[i], _ = strconv.ParseFloat(cell, 64)
+		} else if len(cell) > 0 {
+			values[i] = cell
+		}
+	}
+	ref, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.sheet.SetRow(ref, values)
+}
+
+func (x *xlsxWriter) writeRows(rows [][]string) error {
+	for _, row := range rows {
+		if err := x.writeRow(row, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close finishes the sheet and writes the workbook
+func (x *xlsxWriter) close() error {
+	defer x.file.Close()
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
+
+// xlsxDateValue parses a date, and whether it has a time as well
+func xlsxDateValue(cell string) (time.Time, bool, bool) {
+	for _, layout := range xlsxDateTimeLayouts {
+		if t, err := time.Parse(layout, cell); err == nil {
+			return t.UTC(), true, true
+		}
+	}
+	if t, err := time.Parse("2006-01-02", cell); err == nil {
+		return t, false, true
+	}
+	return time.Time{}, false, false
+}
+
+func printXlsx(ctx *Context, table *TableResult) error {
+	x, err := newXlsxWriter(ctx.Writer, "Results", table.Headers)
+	if err != nil {
+		return err
+	}
+	err = x.writeHeaders(table.Headers)
+	if err == nil {
+		err = x.writeRows(table.Content)
+	}
+	if closeErr := x.close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Reading a sheet of a workbook, such as one written by Excel. Text, numbers, booleans and dates
+// are read as text; formulas are read as their cached values.
+
+// built-in number formats that show dates
+var xlsxBuiltInDateFormats = map[int]bool{14: true, 15: true, 16: true, 17: true, 18: true, 19: true,
+	20: true, 21: true, 22: true, 45: true, 46: true, 47: true}
+
+// date format codes have day, month or year parts outside of quoted text and [colour] sections
+var xlsxDateFormatRegexp = regexp.MustCompile(`[dmy]`)
+var xlsxFormatLiteralRegexp = regexp.MustCompile(`"[^"]*"|\[[^\]]*\]|\\.`)
+
+// readXlsxSheet reads a sheet, chosen by name or number counting from 1, or the 1st sheet if empty
+func readXlsxSheet(r io.ReaderAt, size int64, sheet string) ([][]string, error) {
+	f, err := excelize.OpenReader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, errors.New("not an XLSX workbook")
+	}
+	defer f.Close()
+	name, err := chooseXlsxSheet(f.GetSheetList(), sheet)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := f.GetRows(name, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, err
+	}
+	table := make([][]string, 0)
+	width := 0
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, value := range row {
+			if len(value) == 0 {
+				continue
+			}
+			if cells[j], err = xlsxCellText(f, name, j+1, i+1, value); err != nil {
+				return nil, err
+			}
+			if j+1 > width {
+				width = j + 1
+			}
+		}
+		table = append(table, cells)
+	}
+	// drop trailing empty rows, and make all rows the same width
+	for len(table) > 0 && strings.Join(table[len(table)-1], "") == "" {
+		table = table[:len(table)-1]
+	}
+	for i, row := range table {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		table[i] = row[:width]
+	}
+	return table, nil
+}
+
+func chooseXlsxSheet(names []string, sheet string) (string, error) {
+	if len(names) == 0 {
+		return "", errors.New("workbook has no sheets")
+	}
+	if len(sheet) == 0 {
+		return names[0], nil
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, sheet) {
+			return name, nil
+		}
+	}
+	if number, err := strconv.Atoi(sheet); err == nil && number >= 1 && number <= len(names) {
+		return names[number-1], nil
+	}
+	return "", newCliError(EXIT_VALIDATION, fmt.Sprintf("There is no sheet '%s'. Sheets are '%s'", sheet, strings.Join(names, "', '")))
+}
+
+// xlsxCellText converts the raw value of a cell to text, showing numbers in date formats as dates
+func xlsxCellText(f *excelize.File, sheet string, col, row int, value string) (string, error) {
+	ref, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return "", err
+	}
+	cellType, err := f.GetCellType(sheet, ref)
+	if err != nil {
+		return "", err
+	}
+	switch cellType {
+	case excelize.CellTypeBool:
+		return strconv.FormatBool(value == "1" || strings.EqualFold(value, "true")), nil
+	case excelize.CellTypeUnset, excelize.CellTypeNumber:
+	default:
+		return value, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value, nil
+	}
+	isDate, err := xlsxIsDateCell(f, sheet, ref)
+	if err != nil {
+		return "", err
+	}
+	if isDate {
+		return excelDate(number)
+	}
+	// remove floating point noise, e.g. 0.30000000000000004
+	number, _ = strconv.ParseFloat(strconv.FormatFloat(number, 'g', 15, 64), 64)
+	return strconv.FormatFloat(number, 'f', -1, 64), nil
+}
+
+func xlsxIsDateCell(f *excelize.File, sheet, ref string) (bool, error) {
+	styleId, err := f.GetCellStyle(sheet, ref)
+	if err != nil {
+		return false, err
+	}
+	style, err := f.GetStyle(styleId)
+	if err != nil || style == nil {
+		// cells without a valid style are shown as plain numbers
+		return false, nil
+	}
+	if style.CustomNumFmt != nil {
+		code := xlsxFormatLiteralRegexp.ReplaceAllString(strings.ToLower(*style.CustomNumFmt), "")
+		return xlsxDateFormatRegexp.MatchString(code), nil
+	}
+	return xlsxBuiltInDateFormats[style.NumFmt], nil
+}
+
+// excelDate converts an Excel date serial number to a date, with the time if there is one
+func excelDate(serial float64) (string, error) {
+	t, err := excelize.ExcelDateToTime(serial, false)
+	if err != nil {
+		return "", err
+	}
+	t = t.Round(time.Second)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02"), nil
+	}
+	return t.Format("2006-01-02T15:04"), nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func openTestWorkbook(t *testing.T, workbook []byte) *excelize.File {
+	f, err := excelize.OpenReader(bytes.NewReader(workbook))
+	if err != nil {
+		t.Fatalf("Workbook can't be opened: %v", err)
+	}
+	return f
+}
+
+func TestXlsxOutput(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("xlsx")}
+	table := &TableResult{[]columnDef{columnDef{"Name", 10}, columnDef{"Size", 6}, columnDef{"Created", 16}},
+		[][]string{[]string{"a <b> & c", "1024", "2020-05-20T10:30"}, []string{"007", "", "2020-05-20"}}}
+	if err := printXlsx(ctx, table); err != nil {
+		t.Fatal(err)
+	}
+	workbook := ctx.Writer.(*bytes.Buffer).Bytes()
+	f := openTestWorkbook(t, workbook)
+	defer f.Close()
+	assertEqualString(t, "Results", strings.Join(f.GetSheetList(), ","))
+
+	// cells are stored as text, numbers and dates, shown in date formats
+	expected := []struct {
+		ref, raw  string
+		cellType  excelize.CellType
+		numFormat string
+	}{
+		{"A2", "a <b> & c", excelize.CellTypeInlineString, ""},
+		{"B2", "1024", excelize.CellTypeUnset, ""},
+		{"C2", "43971.4375", excelize.CellTypeUnset, xlsxDateTimeFormat},
+		{"A3", "007", excelize.CellTypeInlineString, ""},
+		{"C3", "43971", excelize.CellTypeUnset, xlsxDateFormat},
+	}
+	for _, cell := range expected {
+		raw, _ := f.GetCellValue("Results", cell.ref, excelize.Options{RawCellValue: true})
+		cellType, _ := f.GetCellType("Results", cell.ref)
+		styleId, _ := f.GetCellStyle("Results", cell.ref)
+		style, _ := f.GetStyle(styleId)
+		numFormat := ""
+		if style != nil && style.CustomNumFmt != nil {
+			numFormat = *style.CustomNumFmt
+		}
+		if raw != cell.raw || cellType != cell.cellType || numFormat != cell.numFormat {
+			t.Errorf("Expected %s to be %+v but was %s, type %v, format '%s'", cell.ref, cell, raw, cellType, numFormat)
+		}
+	}
+	styleId, _ := f.GetCellStyle("Results", "A1")
+	if style, _ := f.GetStyle(styleId); style == nil || style.Font == nil || !style.Font.Bold {
+		t.Errorf("Expected headers to be bold")
+	}
+
+	// dates and numbers read back as they were written
+	read, err := readXlsxSheet(bytes.NewReader(workbook), int64(len(workbook)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualString(t, "Name,Size,Created|a <b> & c,1024,2020-05-20T10:30|007,,2020-05-20", joinTable(read))
+}
+
+func TestStreamXlsx(t *testing.T) {
+	ctx := &Context{Writer: bytes.NewBufferString(""), Format: outputFmt("xlsx")}
+	stream := ctx.newResultStream()
+	stream.writePage(formPage(3, "a", "b"))
+	stream.writePage(formPage(3, "c"))
+	stream.close()
+	f := openTestWorkbook(t, ctx.Writer.(*bytes.Buffer).Bytes())
+	defer f.Close()
+	rows, _ := f.GetRows("Results")
+	if len(rows) != 4 {
+		t.Fatalf("Expected header and 3 rows but got %v", rows)
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// parseYaml parses a YAML document into the same form as parsed JSON. Keys keep their order,
+// and scalars keep their text as written, so that e.g. dates and decimals aren't reformatted.
+func parseYaml(content string) (*jsonNode, error) {
+	var document yaml.Node
+	if err := yaml.NewDecoder(strings.NewReader(content)).Decode(&document); err != nil {
+		if err == io.EOF {
+			return &jsonNode{isList: true}, nil
+		}
+		return nil, err
+	}
+	return yamlToJsonNode(&document)
+}
+
+func yamlToJsonNode(node *yaml.Node) (*jsonNode, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return &jsonNode{isList: true}, nil
+		}
+		return yamlToJsonNode(node.Content[0])
+	case yaml.AliasNode:
+		return yamlToJsonNode(node.Alias)
+	case yaml.SequenceNode:
+		list := &jsonNode{isList: true}
+		for _, item := range node.Content {
+			child, err := yamlToJsonNode(item)
+			if err != nil {
+				return nil, err
+			}
+			list.values = append(list.values, child)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		return yamlMapping(node)
+	}
+	switch node.ShortTag() {
+	case "!!null":
+		return &jsonNode{}, nil
+	case "!!bool":
+		var value bool
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return &jsonNode{scalar: value}, nil
+	case "!!int", "!!float":
+		return &jsonNode{scalar: json.Number(node.Value)}, nil
+	}
+	return &jsonNode{scalar: node.Value}, nil
+}
+
+// yamlMapping converts a mapping, including the keys of any mappings merged into it with '<<'
+func yamlMapping(node *yaml.Node) (*jsonNode, error) {
+	mapping := &jsonNode{isMap: true}
+	seen := make(map[string]int)
+	set := func(key string, value *jsonNode) {
+		if i, exists := seen[key]; exists {
+			mapping.values[i] = value
+			return
+		}
+		seen[key] = len(mapping.keys)
+		mapping.keys = append(mapping.keys, key)
+		mapping.values = append(mapping.values, value)
+	}
+	merged := make([]*jsonNode, 0)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		child, err := yamlToJsonNode(value)
+		if err != nil {
+			return nil, err
+		}
+		if key.ShortTag() == "!!merge" {
+			if child.isList {
+				merged = append(merged, child.values...)
+			} else {
+				merged = append(merged, child)
+			}
+			continue
+		}
+		if key.Kind != yaml.ScalarNode {
+			return nil, errors.New(fmt.Sprintf("line %d: keys must be simple values", key.Line))
+		}
+		set(key.Value, child)
+	}
+	// keys set in the mapping itself take precedence over merged keys
+	for _, source := range merged {
+		if !source.isMap {
+			return nil, errors.New(fmt.Sprintf("line %d: only mappings can be merged with '<<'", node.Line))
+		}
+		for i, key := range source.keys {
+			if _, exists := seen[key]; !exists {
+				set(key, source.values[i])
+			}
+		}
+	}
+	return mapping, nil
+}